@@ -21,6 +21,14 @@ type Expression interface {
 type Identifier struct {
 	Token	token.Token
 	Value	string
+	// Scope, Depth and Slot are set by the evaluator's resolver. A name
+	// bound in a local environment is found Depth environments out, in
+	// slot Slot of Scope. A name the resolver found no local binding for
+	// has a nil Scope and is looked up by name, starting Depth
+	// environments out.
+	Scope	*Scope
+	Depth	int
+	Slot	int
 }
 
 func (i *Identifier) expressionNode() {}
@@ -90,6 +98,47 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+type PropertyExpression struct {
+	Token		token.Token
+	Left		Expression
+	Property	*Identifier
+}
+func (pe *PropertyExpression) expressionNode() {}
+func (pe *PropertyExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+func (pe *PropertyExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(".")
+	out.WriteString(pe.Property.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+type HashLiteral struct {
+	Token	token.Token
+	Keys	[]Expression
+	Values	[]Expression
+}
+func (hl *HashLiteral) expressionNode() {}
+func (hl *HashLiteral) TokenLiteral() string {
+	return hl.Token.Literal
+}
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+
+	out.WriteString("{")
+	for i, key := range hl.Keys {
+		pairs = append(pairs, key.String()+": "+hl.Values[i].String())
+	}
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
 type IndexExpression struct {
 	Token		token.Token
 	Left		Expression
@@ -113,8 +162,13 @@ func (ie *IndexExpression) String() string {
 
 type FunctionLiteral struct {
 	Token 		token.Token
-	Parameters 	[]*Identifier
+	// Parameters are BindingPatterns for plain names and other patterns
+	// for parameters that destructure their argument.
+	Parameters 	[]Pattern
 	Body		*BlockStatement
+	// Scope lays out the parameters and the names the body binds, once
+	// resolved.
+	Scope		*Scope
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -128,8 +182,8 @@ func (fl *FunctionLiteral) String() string {
 	out.WriteString(fl.Token.Literal)
 	out.WriteString("(")
 	
-	for _, identifier := range fl.Parameters {
-		params = append(params, identifier.String())
+	for _, param := range fl.Parameters {
+		params = append(params, param.String())
 	}
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
@@ -137,12 +191,40 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+// MacroLiteral is macro(params) { body }. Macros are bound by top-level
+// let statements and expanded away before the program is evaluated.
+type MacroLiteral struct {
+	Token		token.Token
+	Parameters	[]*Identifier
+	Body		*BlockStatement
+}
+
+func (ml *MacroLiteral) expressionNode() {}
+func (ml *MacroLiteral) TokenLiteral() string {
+	return ml.Token.Literal
+}
+func (ml *MacroLiteral) String() string {
+	var out bytes.Buffer
+	params := []string{}
+
+	out.WriteString(ml.Token.Literal)
+	out.WriteString("(")
+	for _, identifier := range ml.Parameters {
+		params = append(params, identifier.String())
+	}
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	out.WriteString(ml.Body.String())
+	return out.String()
+}
 
 type IfExpression struct {
 	Token 		token.Token
 	Condition	Expression
 	Consequence	*BlockStatement
-	Alternative *BlockStatement 
+	// Alternative is a *BlockStatement, an *IfExpression for else if,
+	// or nil.
+	Alternative Node
 }
 
 func (ie *IfExpression) expressionNode() {}
@@ -158,16 +240,57 @@ func (ie *IfExpression) String() string {
 	out.WriteString(ie.Consequence.String())
 
 	if ie.Alternative != nil {
-		out.WriteString("else ")
+		out.WriteString(" else ")
 		out.WriteString(ie.Alternative.String())
 	}
 	return out.String()
 }
 
 
+type TryExpression struct {
+	Token	token.Token
+	Block	*BlockStatement
+	// Param is nil when the caught error is not bound, as in catch { }.
+	Param	*Identifier
+	Catch	*BlockStatement
+	Finally	*BlockStatement
+	// CatchScope lays out Param once resolved.
+	CatchScope	*Scope
+}
+
+func (te *TryExpression) expressionNode() {}
+func (te *TryExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Block.String())
+	if te.Catch != nil {
+		out.WriteString(" catch")
+		if te.Param != nil {
+			out.WriteString("(" + te.Param.String() + ")")
+		}
+		out.WriteString(" ")
+		out.WriteString(te.Catch.String())
+	}
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+	return out.String()
+}
+
 type BlockStatement struct {
 	Token		token.Token
 	Statements	[]Statement
+	// Rbrace is the closing brace, for tools that need to know where the
+	// block ends.
+	Rbrace		token.Token
+	// Scope lays out the names the block binds, once resolved. Blocks
+	// that bind nothing share the enclosing environment and have none.
+	Scope		*Scope
 }
 func (bs *BlockStatement) statementNode() {}
 func (bs *BlockStatement) TokenLiteral() string {
@@ -241,6 +364,19 @@ func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -253,6 +389,29 @@ func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+}
+func (is *InterpolatedString) expressionNode() {}
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	for _, part := range is.Parts {
+		if sl, ok := part.(*StringLiteral); ok {
+			out.WriteString(sl.Value)
+			continue
+		}
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	return out.String()
+}
+
 type Statement interface {
 	Node
 	statementNode()
@@ -277,7 +436,9 @@ func (es *ExpressionStatement) String() string {
 
 type LetStatement struct {
 	Token	token.Token
+	// Name is nil when the value is destructured with Pattern.
 	Name	*Identifier
+	Pattern	Pattern
 	Value	Expression	
 }
 
@@ -289,11 +450,16 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.TokenLiteral())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.TokenLiteral())
+	}
 	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
 	}
+	out.WriteString(";")
 	return out.String()
 }
 
@@ -314,9 +480,238 @@ func (rs *ReturnStatement) String() string {
 	if rs.ReturnValue != nil {
 		out.WriteString(rs.ReturnValue.String())
 	}
+	out.WriteString(";")
+	return out.String()
+}
+
+
+type ThrowStatement struct {
+	Token	token.Token
+	Value	Expression
+}
+
+func (ts *ThrowStatement) statementNode() {}
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+func (ts *ThrowStatement) String() string {
+	return ts.TokenLiteral() + " " + ts.Value.String() + ";"
+}
+
+type ImportStatement struct {
+	Token	token.Token
+	Path	string
+	// Alias is nil when the module is bound to the base name of Path.
+	Alias	*Identifier
+}
+
+func (is *ImportStatement) statementNode() {}
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(`"` + is.Path + `"`)
+	if is.Alias != nil {
+		out.WriteString(" as " + is.Alias.String())
+	}
+	out.WriteString(";")
 	return out.String()
 }
 
+type ExportStatement struct {
+	Token		token.Token
+	Statement	*LetStatement
+}
+
+func (es *ExportStatement) statementNode() {}
+func (es *ExportStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
+
+type MatchExpression struct {
+	Token	token.Token
+	Subject	Expression
+	Arms	[]*MatchArm
+}
+
+func (me *MatchExpression) expressionNode() {}
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+	return out.String()
+}
+
+type MatchArm struct {
+	Token	token.Token
+	Pattern	Pattern
+	// Guard is nil for arms without an if clause.
+	Guard	Expression
+	// Body is an Expression or a *BlockStatement.
+	Body	Node
+	// Scope lays out the names Pattern binds, once resolved.
+	Scope	*Scope
+}
+
+func (ma *MatchArm) TokenLiteral() string {
+	return ma.Token.Literal
+}
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if " + ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	if block, ok := ma.Body.(*BlockStatement); ok {
+		out.WriteString("{ " + block.String() + " }")
+	} else {
+		out.WriteString(ma.Body.String())
+	}
+	return out.String()
+}
+
+// Pattern is matched against a value, binding names to parts of it.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+type WildcardPattern struct {
+	Token	token.Token
+}
+
+func (wp *WildcardPattern) patternNode() {}
+func (wp *WildcardPattern) TokenLiteral() string {
+	return wp.Token.Literal
+}
+func (wp *WildcardPattern) String() string {
+	return "_"
+}
+
+type BindingPattern struct {
+	Name	*Identifier
+}
+
+func (bp *BindingPattern) patternNode() {}
+func (bp *BindingPattern) TokenLiteral() string {
+	return bp.Name.TokenLiteral()
+}
+func (bp *BindingPattern) String() string {
+	return bp.Name.String()
+}
+
+// LiteralPattern matches values equal to a literal, which may be a
+// negated number.
+type LiteralPattern struct {
+	Token	token.Token
+	Value	Expression
+}
+
+func (lp *LiteralPattern) patternNode() {}
+func (lp *LiteralPattern) TokenLiteral() string {
+	return lp.Token.Literal
+}
+func (lp *LiteralPattern) String() string {
+	switch value := lp.Value.(type) {
+	case *StringLiteral:
+		return `"` + value.Value + `"`
+	case *PrefixExpression:
+		return value.Operator + value.Right.String()
+	default:
+		return lp.Value.String()
+	}
+}
+
+type AlternativePattern struct {
+	Token		token.Token
+	Alternatives	[]Pattern
+}
+
+func (ap *AlternativePattern) patternNode() {}
+func (ap *AlternativePattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+func (ap *AlternativePattern) String() string {
+	alternatives := []string{}
+	for _, alt := range ap.Alternatives {
+		alternatives = append(alternatives, alt.String())
+	}
+	return strings.Join(alternatives, " | ")
+}
+
+type ArrayPattern struct {
+	Token		token.Token
+	Elements	[]Pattern
+	// Rest, if not nil, collects the elements after Elements, so that
+	// longer arrays match too.
+	Rest		*Identifier
+}
+
+func (ap *ArrayPattern) patternNode() {}
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPattern matches hashes that have all of Keys, whatever else they
+// hold. A bare identifier key stands for the string of the same name.
+type HashPattern struct {
+	Token	token.Token
+	Keys	[]Expression
+	Values	[]Pattern
+}
+
+func (hp *HashPattern) patternNode() {}
+func (hp *HashPattern) TokenLiteral() string {
+	return hp.Token.Literal
+}
+func (hp *HashPattern) String() string {
+	pairs := []string{}
+	for i, key := range hp.Keys {
+		k := key.String()
+		if str, ok := key.(*StringLiteral); ok && str.Token.Type != token.IDENT {
+			k = `"` + str.Value + `"`
+		}
+		pairs = append(pairs, k+": "+hp.Values[i].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Scope lists the names bound in a local environment, that is one other
+// than a program's, so that they can be kept in a slice: each name's
+// slot is its index in Names.
+type Scope struct {
+	Names	[]string
+}
 
 type Program struct {
 	Statements []Statement
@@ -0,0 +1,136 @@
+package ast
+
+// ModifierFunc returns the node to put in place of the node it is given.
+type ModifierFunc func(Node) Node
+
+// Modify rewrites node bottom up: the children of a node are modified,
+// in place and in the order Walk visits them, before modifier is called
+// on the node itself. It returns what modifier returned for node. A
+// child replaced by a node of the wrong kind for its place, such as a
+// statement where an expression belongs, is set to nil.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	case *Program:
+		for i, statement := range node.Statements {
+			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+		}
+	case *BlockStatement:
+		for i, statement := range node.Statements {
+			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+		}
+	case *ExpressionStatement:
+		if node.Expression != nil {
+			node.Expression, _ = Modify(node.Expression, modifier).(Expression)
+		}
+	case *LetStatement:
+		if node.Name != nil {
+			node.Name, _ = Modify(node.Name, modifier).(*Identifier)
+		}
+		if node.Pattern != nil {
+			node.Pattern, _ = Modify(node.Pattern, modifier).(Pattern)
+		}
+		if node.Value != nil {
+			node.Value, _ = Modify(node.Value, modifier).(Expression)
+		}
+	case *ReturnStatement:
+		if node.ReturnValue != nil {
+			node.ReturnValue, _ = Modify(node.ReturnValue, modifier).(Expression)
+		}
+	case *ThrowStatement:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *ImportStatement:
+		if node.Alias != nil {
+			node.Alias, _ = Modify(node.Alias, modifier).(*Identifier)
+		}
+	case *ExportStatement:
+		node.Statement, _ = Modify(node.Statement, modifier).(*LetStatement)
+	case *InterpolatedString:
+		for i, part := range node.Parts {
+			node.Parts[i], _ = Modify(part, modifier).(Expression)
+		}
+	case *PrefixExpression:
+		node.Right, _ = Modify(node.Right, modifier).(Expression)
+	case *InfixExpression:
+		node.Left, _ = Modify(node.Left, modifier).(Expression)
+		node.Right, _ = Modify(node.Right, modifier).(Expression)
+	case *CallExpression:
+		node.Function, _ = Modify(node.Function, modifier).(Expression)
+		for i, argument := range node.Arguments {
+			node.Arguments[i], _ = Modify(argument, modifier).(Expression)
+		}
+	case *ArrayLiteral:
+		for i, element := range node.Elements {
+			node.Elements[i], _ = Modify(element, modifier).(Expression)
+		}
+	case *HashLiteral:
+		for i := range node.Keys {
+			node.Keys[i], _ = Modify(node.Keys[i], modifier).(Expression)
+			node.Values[i], _ = Modify(node.Values[i], modifier).(Expression)
+		}
+	case *IndexExpression:
+		node.Left, _ = Modify(node.Left, modifier).(Expression)
+		node.Index, _ = Modify(node.Index, modifier).(Expression)
+	case *PropertyExpression:
+		node.Left, _ = Modify(node.Left, modifier).(Expression)
+		node.Property, _ = Modify(node.Property, modifier).(*Identifier)
+	case *FunctionLiteral:
+		for i, param := range node.Parameters {
+			node.Parameters[i], _ = Modify(param, modifier).(Pattern)
+		}
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+	case *MacroLiteral:
+		for i, param := range node.Parameters {
+			node.Parameters[i], _ = Modify(param, modifier).(*Identifier)
+		}
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+	case *IfExpression:
+		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
+		node.Consequence, _ = Modify(node.Consequence, modifier).(*BlockStatement)
+		if node.Alternative != nil {
+			node.Alternative = Modify(node.Alternative, modifier)
+		}
+	case *TryExpression:
+		node.Block, _ = Modify(node.Block, modifier).(*BlockStatement)
+		if node.Param != nil {
+			node.Param, _ = Modify(node.Param, modifier).(*Identifier)
+		}
+		if node.Catch != nil {
+			node.Catch, _ = Modify(node.Catch, modifier).(*BlockStatement)
+		}
+		if node.Finally != nil {
+			node.Finally, _ = Modify(node.Finally, modifier).(*BlockStatement)
+		}
+	case *MatchExpression:
+		node.Subject, _ = Modify(node.Subject, modifier).(Expression)
+		for i, arm := range node.Arms {
+			node.Arms[i], _ = Modify(arm, modifier).(*MatchArm)
+		}
+	case *MatchArm:
+		node.Pattern, _ = Modify(node.Pattern, modifier).(Pattern)
+		if node.Guard != nil {
+			node.Guard, _ = Modify(node.Guard, modifier).(Expression)
+		}
+		node.Body = Modify(node.Body, modifier)
+	case *BindingPattern:
+		node.Name, _ = Modify(node.Name, modifier).(*Identifier)
+	case *LiteralPattern:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *AlternativePattern:
+		for i, alternative := range node.Alternatives {
+			node.Alternatives[i], _ = Modify(alternative, modifier).(Pattern)
+		}
+	case *ArrayPattern:
+		for i, element := range node.Elements {
+			node.Elements[i], _ = Modify(element, modifier).(Pattern)
+		}
+		if node.Rest != nil {
+			node.Rest, _ = Modify(node.Rest, modifier).(*Identifier)
+		}
+	case *HashPattern:
+		for i := range node.Keys {
+			node.Keys[i], _ = Modify(node.Keys[i], modifier).(Expression)
+			node.Values[i], _ = Modify(node.Values[i], modifier).(Pattern)
+		}
+	}
+	return modifier(node)
+}
@@ -0,0 +1,163 @@
+package ast
+
+// A Visitor's Visit method is called for each node found by Walk. If it
+// returns a Visitor w, Walk visits the children of the node with w and
+// then calls w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, visiting the children of
+// each node in source order. Optional children that are absent, such as
+// the alternative of an if without else, are skipped.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			Walk(v, stmt)
+		}
+	case *BlockStatement:
+		for _, stmt := range n.Statements {
+			Walk(v, stmt)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Pattern != nil {
+			Walk(v, n.Pattern)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			Walk(v, n.ReturnValue)
+		}
+	case *ThrowStatement:
+		Walk(v, n.Value)
+	case *ImportStatement:
+		if n.Alias != nil {
+			Walk(v, n.Alias)
+		}
+	case *ExportStatement:
+		Walk(v, n.Statement)
+	case *Identifier, *BooleanExpression, *IntegerLiteral, *FloatLiteral, *StringLiteral:
+		// leaves
+	case *InterpolatedString:
+		for _, part := range n.Parts {
+			Walk(v, part)
+		}
+	case *PrefixExpression:
+		Walk(v, n.Right)
+	case *InfixExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *CallExpression:
+		Walk(v, n.Function)
+		for _, arg := range n.Arguments {
+			Walk(v, arg)
+		}
+	case *ArrayLiteral:
+		for _, element := range n.Elements {
+			Walk(v, element)
+		}
+	case *HashLiteral:
+		for i := range n.Keys {
+			Walk(v, n.Keys[i])
+			Walk(v, n.Values[i])
+		}
+	case *IndexExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Index)
+	case *PropertyExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Property)
+	case *FunctionLiteral:
+		for _, param := range n.Parameters {
+			Walk(v, param)
+		}
+		Walk(v, n.Body)
+	case *MacroLiteral:
+		for _, param := range n.Parameters {
+			Walk(v, param)
+		}
+		Walk(v, n.Body)
+	case *IfExpression:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+	case *TryExpression:
+		Walk(v, n.Block)
+		if n.Param != nil {
+			Walk(v, n.Param)
+		}
+		if n.Catch != nil {
+			Walk(v, n.Catch)
+		}
+		if n.Finally != nil {
+			Walk(v, n.Finally)
+		}
+	case *MatchExpression:
+		Walk(v, n.Subject)
+		for _, arm := range n.Arms {
+			Walk(v, arm)
+		}
+	case *MatchArm:
+		Walk(v, n.Pattern)
+		if n.Guard != nil {
+			Walk(v, n.Guard)
+		}
+		Walk(v, n.Body)
+	case *WildcardPattern:
+		// leaf
+	case *BindingPattern:
+		Walk(v, n.Name)
+	case *LiteralPattern:
+		Walk(v, n.Value)
+	case *AlternativePattern:
+		for _, alternative := range n.Alternatives {
+			Walk(v, alternative)
+		}
+	case *ArrayPattern:
+		for _, element := range n.Elements {
+			Walk(v, element)
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+	case *HashPattern:
+		for i := range n.Keys {
+			Walk(v, n.Keys[i])
+			Walk(v, n.Values[i])
+		}
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order like Walk, calling f for
+// each node and then f(nil) once its children are done. The children of
+// a node are skipped when f returns false for it.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,143 @@
+package ast_test
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+// everyNode uses every kind of node at least once.
+const everyNode = `import "lib" as l;
+export let total = fn(a, [b, ...rest], {c}) { return a + b * c; };
+let [x, _] = [1, 2.5];
+let h = {"k": -x, 1: "s${x}e"};
+let m = macro(q) { quote(unquote(q)) };
+if (h["k"] < 0) { l.f(x) } else if (true) { 1 } else { 2 };
+try { throw "e" } catch (err) { err } finally { 3 };
+match (x) { 1 | 2 => 1, [y, ...ys] if y => y, {k: z} => z, _ => 0 };
+`
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.MakeNewParser(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %q", p.Errors())
+	}
+	return program
+}
+
+func TestInspectVisitsEveryNodeType(t *testing.T) {
+	program := parse(t, everyNode)
+
+	seen := map[string]int{}
+	depth := 0
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node == nil {
+			depth--
+			return false
+		}
+		depth++
+		seen[strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")]++
+		return true
+	})
+
+	if depth != 0 {
+		t.Errorf("unbalanced nil visits. depth=%d", depth)
+	}
+	types := []string{
+		"Program", "BlockStatement", "ExpressionStatement", "LetStatement", "ReturnStatement",
+		"ThrowStatement", "ImportStatement", "ExportStatement", "Identifier", "BooleanExpression",
+		"IntegerLiteral", "FloatLiteral", "StringLiteral", "InterpolatedString", "PrefixExpression",
+		"InfixExpression", "CallExpression", "ArrayLiteral", "HashLiteral", "IndexExpression",
+		"PropertyExpression", "FunctionLiteral", "MacroLiteral", "IfExpression", "TryExpression",
+		"MatchExpression", "MatchArm", "WildcardPattern", "BindingPattern", "LiteralPattern",
+		"AlternativePattern", "ArrayPattern", "HashPattern",
+	}
+	for _, typ := range types {
+		if seen[typ] == 0 {
+			t.Errorf("%s not visited", typ)
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	program := parse(t, `let f = fn(x) { x + 1 }; f(2)`)
+
+	var identifiers []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		if _, ok := node.(*ast.FunctionLiteral); ok {
+			return false
+		}
+		if ident, ok := node.(*ast.Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		return true
+	})
+
+	if strings.Join(identifiers, " ") != "f f" {
+		t.Errorf("wrong identifiers. got=%q", identifiers)
+	}
+}
+
+func TestModify(t *testing.T) {
+	turnOneIntoTwo := func(node ast.Node) ast.Node {
+		integer, ok := node.(*ast.IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+		integer.Value = 2
+		integer.Token.Literal = "2"
+		return integer
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1`, `2`},
+		{`1 + 2`, `(2 + 2)`},
+		{`-1`, `(-2)`},
+		{`a[1]`, `(a[2])`},
+		{`[1, 1]`, `[2, 2]`},
+		{`{1: 1}`, `{2: 2}`},
+		{`f(1)`, `f(2)`},
+		{`"a${1}b"`, `${2}`},
+		{`if (1) { 1 } else if (1) { 1 } else { 1 }`, `if2 2 else if2 2 else 2`},
+		{`let x = 1;`, `let x = 2;`},
+		{`return 1;`, `return 2;`},
+		{`throw 1;`, `throw 2;`},
+		{`fn(x) { 1 }`, `fn(x)2`},
+		{`try { 1 } catch (e) { 1 } finally { 1 }`, `try 2 catch(e) 2 finally 2`},
+		{`match (1) { 1 if 1 => 1 }`, `match (2) { 2 if 2 => 2 }`},
+		{`match (x) { [1, ...r] | {a: 1} => 1 }`, `match (x) { [2, ...r] | {a: 2} => 2 }`},
+		{`export let x = 1;`, `export let x = 2;`},
+	}
+
+	for _, tt := range tests {
+		modified := ast.Modify(parse(t, tt.input), turnOneIntoTwo)
+		if !strings.Contains(modified.String(), tt.expected) {
+			t.Errorf("%s: not modified. expected %q in %q", tt.input, tt.expected, modified.String())
+		}
+	}
+}
+
+func TestModifyReplacesIdentifiers(t *testing.T) {
+	program := parse(t, `let x = fn(x, [y, ...x]) { x }; match (x) { x => x }; try { x } catch (x) { x }`)
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == "x" {
+			return &ast.Identifier{Token: ident.Token, Value: "z"}
+		}
+		return node
+	})
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Value == "x" {
+			t.Errorf("x left at line %d, column %d", ident.Token.Line, ident.Token.Column)
+		}
+		return true
+	})
+}
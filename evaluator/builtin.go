@@ -1,11 +1,19 @@
 package evaluator
 
-import "monkey/object"
+import (
+	"fmt"
+	"io"
+	"math"
+	"monkey/object"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
 
 
 var Builtins = map[string]*object.Builtin {
 	"len" : {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments. got=%d, want=1",
 						len(args))
@@ -13,11 +21,824 @@ var Builtins = map[string]*object.Builtin {
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
 			default:
 				return newError("argument to len not supported, got %s",
 				args[0].Type())
 			}
 		},
 	},
-}
\ No newline at end of file
+	"split": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			s, sep, err := stringArgs("split", args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return track(rt, stringArray(strings.Split(s, sep)))
+		},
+	},
+	"join": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `join` must be ARRAY, got %s",
+					args[0].Type())
+			}
+			sep, ok := args[1].(*object.String)
+			if !ok {
+				return newError("argument to `join` must be STRING, got %s",
+					args[1].Type())
+			}
+			parts := make([]string, len(arr.Elements))
+			for i, e := range arr.Elements {
+				parts[i] = e.Inspect()
+			}
+			return track(rt, &object.String{Value: strings.Join(parts, sep.Value)})
+		},
+	},
+	"trim": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			s, _, err := stringArgs("trim", args[0])
+			if err != nil {
+				return err
+			}
+			return track(rt, &object.String{Value: strings.TrimSpace(s)})
+		},
+	},
+	"upper": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			s, _, err := stringArgs("upper", args[0])
+			if err != nil {
+				return err
+			}
+			return track(rt, &object.String{Value: strings.ToUpper(s)})
+		},
+	},
+	"lower": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			s, _, err := stringArgs("lower", args[0])
+			if err != nil {
+				return err
+			}
+			return track(rt, &object.String{Value: strings.ToLower(s)})
+		},
+	},
+	"contains": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			if arr, ok := args[0].(*object.Array); ok {
+				return nativeBooleanObject(arrayIndex(arr, args[1]) >= 0)
+			}
+			s, sub, err := stringArgs("contains", args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return nativeBooleanObject(strings.Contains(s, sub))
+		},
+	},
+	"startsWith": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			s, prefix, err := stringArgs("startsWith", args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return nativeBooleanObject(strings.HasPrefix(s, prefix))
+		},
+	},
+	"endsWith": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			s, suffix, err := stringArgs("endsWith", args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return nativeBooleanObject(strings.HasSuffix(s, suffix))
+		},
+	},
+	"replace": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3",
+					len(args))
+			}
+			s, old, err := stringArgs("replace", args[0], args[1])
+			if err != nil {
+				return err
+			}
+			replacement, _, err := stringArgs("replace", args[2])
+			if err != nil {
+				return err
+			}
+			return track(rt, &object.String{Value: strings.ReplaceAll(s, old, replacement)})
+		},
+	},
+	"indexOf": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			if arr, ok := args[0].(*object.Array); ok {
+				return &object.Integer{Value: int64(arrayIndex(arr, args[1]))}
+			}
+			s, sub, err := stringArgs("indexOf", args[0], args[1])
+			if err != nil {
+				return err
+			}
+			i := strings.Index(s, sub)
+			if i < 0 {
+				return &object.Integer{Value: -1}
+			}
+			// Like substr, count in characters rather than bytes.
+			return &object.Integer{Value: int64(utf8.RuneCountInString(s[:i]))}
+		},
+	},
+	"repeat": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			s, _, err := stringArgs("repeat", args[0])
+			if err != nil {
+				return err
+			}
+			count, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument to `repeat` must be INTEGER, got %s",
+					args[1].Type())
+			}
+			if count.Value < 0 {
+				return newError("negative repeat count: %d", count.Value)
+			}
+			if len(s) > 0 && count.Value > int64(math.MaxInt/len(s)) {
+				return newError("repeat count too large: %d", count.Value)
+			}
+			if err := rt.Allocate(repeatedSize(int64(len(s)), count.Value)); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.Repeat(s, int(count.Value))}
+		},
+	},
+	"substr": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=2 or 3",
+					len(args))
+			}
+			s, _, err := stringArgs("substr", args[0])
+			if err != nil {
+				return err
+			}
+			start, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument to `substr` must be INTEGER, got %s",
+					args[1].Type())
+			}
+			runes := []rune(s)
+			if start.Value < 0 || start.Value > int64(len(runes)) {
+				return newError("substr start out of range: %d", start.Value)
+			}
+			end := int64(len(runes))
+			if len(args) == 3 {
+				length, ok := args[2].(*object.Integer)
+				if !ok {
+					return newError("argument to `substr` must be INTEGER, got %s",
+						args[2].Type())
+				}
+				if length.Value < 0 {
+					return newError("negative substr length: %d", length.Value)
+				}
+				if length.Value < end-start.Value {
+					end = start.Value + length.Value
+				}
+			}
+			return track(rt, &object.String{Value: string(runes[start.Value:end])})
+		},
+	},
+	"chars": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			s, _, err := stringArgs("chars", args[0])
+			if err != nil {
+				return err
+			}
+			chars := []string{}
+			for _, r := range s {
+				chars = append(chars, string(r))
+			}
+			return track(rt, stringArray(chars))
+		},
+	},
+	"first": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			arr, err := arrayArg("first", args[0])
+			if err != nil {
+				return err
+			}
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+			return arr.Elements[0]
+		},
+	},
+	"last": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			arr, err := arrayArg("last", args[0])
+			if err != nil {
+				return err
+			}
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+			return arr.Elements[len(arr.Elements)-1]
+		},
+	},
+	"rest": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			arr, err := arrayArg("rest", args[0])
+			if err != nil {
+				return err
+			}
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+			return track(rt, newArray(arr.Elements[1:]))
+		},
+	},
+	"push": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			arr, err := arrayArg("push", args[0])
+			if err != nil {
+				return err
+			}
+			return track(rt, newArray(arr.Elements, args[1]))
+		},
+	},
+	// pop returns a new array without the last element, leaving the
+	// argument untouched like push does.
+	"pop": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			arr, err := arrayArg("pop", args[0])
+			if err != nil {
+				return err
+			}
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+			return track(rt, newArray(arr.Elements[:len(arr.Elements)-1]))
+		},
+	},
+	"slice": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=2 or 3",
+					len(args))
+			}
+			arr, err := arrayArg("slice", args[0])
+			if err != nil {
+				return err
+			}
+			bounds, err := integerArgs("slice", args[1:]...)
+			if err != nil {
+				return err
+			}
+			start, end := bounds[0], int64(len(arr.Elements))
+			if len(bounds) == 2 {
+				end = bounds[1]
+			}
+			if start < 0 || end > int64(len(arr.Elements)) || start > end {
+				return newError("slice bounds out of range: [%d:%d] with length %d",
+					start, end, len(arr.Elements))
+			}
+			return track(rt, newArray(arr.Elements[start:end]))
+		},
+	},
+	"concat": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			elements := []object.Object{}
+			for _, arg := range args {
+				arr, err := arrayArg("concat", arg)
+				if err != nil {
+					return err
+				}
+				elements = append(elements, arr.Elements...)
+			}
+			return track(rt, &object.Array{Elements: elements})
+		},
+	},
+	"reverse": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			arr, err := arrayArg("reverse", args[0])
+			if err != nil {
+				return err
+			}
+			length := len(arr.Elements)
+			elements := make([]object.Object, length)
+			for i, e := range arr.Elements {
+				elements[length-i-1] = e
+			}
+			return track(rt, &object.Array{Elements: elements})
+		},
+	},
+	"range": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1 to 3",
+					len(args))
+			}
+			bounds, err := integerArgs("range", args...)
+			if err != nil {
+				return err
+			}
+			start, end, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("range step must not be zero")
+			}
+			count := rangeLength(start, end, step)
+			if count > (math.MaxInt64-objectOverhead)/elementSize {
+				return newError("range too large: %d elements", count)
+			}
+			if err := rt.Allocate(arraySize(int64(count))); err != nil {
+				return err
+			}
+			elements := []object.Object{}
+			for i, n := start, uint64(0); n < count; i, n = i+step, n+1 {
+				elements = append(elements, &object.Integer{Value: i})
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"zip": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			left, err := arrayArg("zip", args[0])
+			if err != nil {
+				return err
+			}
+			right, err := arrayArg("zip", args[1])
+			if err != nil {
+				return err
+			}
+			length := len(left.Elements)
+			if len(right.Elements) < length {
+				length = len(right.Elements)
+			}
+			if err := rt.Allocate(int64(length) * arraySize(2)); err != nil {
+				return err
+			}
+			pairs := make([]object.Object, length)
+			for i := 0; i < length; i++ {
+				pairs[i] = newArray(nil, left.Elements[i], right.Elements[i])
+			}
+			return track(rt, &object.Array{Elements: pairs})
+		},
+	},
+	"map": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := callbackArgs("map", args)
+			if err != nil {
+				return err
+			}
+			mapped := make([]object.Object, len(arr.Elements))
+			for i, element := range arr.Elements {
+				result := rt.Apply(fn, element)
+				if isError(result) {
+					return result
+				}
+				mapped[i] = result
+			}
+			return track(rt, &object.Array{Elements: mapped})
+		},
+	},
+	"filter": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := callbackArgs("filter", args)
+			if err != nil {
+				return err
+			}
+			filtered := []object.Object{}
+			for _, element := range arr.Elements {
+				result := rt.Apply(fn, element)
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					filtered = append(filtered, element)
+				}
+			}
+			return track(rt, &object.Array{Elements: filtered})
+		},
+	},
+	"reduce": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3",
+					len(args))
+			}
+			arr, fn, err := callbackArgs("reduce", []object.Object{args[0], args[2]})
+			if err != nil {
+				return err
+			}
+			result := args[1]
+			for _, element := range arr.Elements {
+				result = rt.Apply(fn, result, element)
+				if isError(result) {
+					return result
+				}
+			}
+			return result
+		},
+	},
+	// sortBy sorts with a comparator when given fn(a, b), which reports
+	// whether a goes before b, and by key for any other function.
+	"sortBy": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := callbackArgs("sortBy", args)
+			if err != nil {
+				return err
+			}
+			sorted := newArray(arr.Elements)
+			if f, ok := fn.(*object.Function); ok && len(f.Parameters) == 2 {
+				var failed object.Object
+				sort.SliceStable(sorted.Elements, func(i, j int) bool {
+					if failed != nil {
+						return false
+					}
+					result := rt.Apply(fn, sorted.Elements[i], sorted.Elements[j])
+					if isError(result) {
+						failed = result
+						return false
+					}
+					return isTruthy(result)
+				})
+				if failed != nil {
+					return failed
+				}
+				return track(rt, sorted)
+			}
+			return track(rt, sortByKey(rt, sorted, fn))
+		},
+	},
+	"any": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := callbackArgs("any", args)
+			if err != nil {
+				return err
+			}
+			for _, element := range arr.Elements {
+				result := rt.Apply(fn, element)
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					return TRUE
+				}
+			}
+			return FALSE
+		},
+	},
+	"all": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := callbackArgs("all", args)
+			if err != nil {
+				return err
+			}
+			for _, element := range arr.Elements {
+				result := rt.Apply(fn, element)
+				if isError(result) {
+					return result
+				}
+				if !isTruthy(result) {
+					return FALSE
+				}
+			}
+			return TRUE
+		},
+	},
+	"find": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := callbackArgs("find", args)
+			if err != nil {
+				return err
+			}
+			for _, element := range arr.Elements {
+				result := rt.Apply(fn, element)
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					return element
+				}
+			}
+			return NULL
+		},
+	},
+	"groupBy": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			arr, fn, err := callbackArgs("groupBy", args)
+			if err != nil {
+				return err
+			}
+			groups := object.NewHash()
+			for _, element := range arr.Elements {
+				result := rt.Apply(fn, element)
+				if isError(result) {
+					return result
+				}
+				key, ok := result.(object.Hashable)
+				if !ok {
+					return newError("unusable as hash key: %s", result.Type())
+				}
+				group, ok := groups.Get(key)
+				if !ok {
+					group = &object.Array{}
+				}
+				group.(*object.Array).Elements = append(group.(*object.Array).Elements, element)
+				groups.Set(key, group)
+			}
+			return track(rt, groups)
+		},
+	},
+	"puts": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			for _, arg := range args {
+				if _, err := fmt.Fprintln(rt.Writer(), arg.Inspect()); err != nil {
+					return newError("puts: %s", err)
+				}
+			}
+			return NULL
+		},
+	},
+	"print": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			parts := make([]string, len(args))
+			for i, arg := range args {
+				parts[i] = arg.Inspect()
+			}
+			if _, err := io.WriteString(rt.Writer(), strings.Join(parts, " ")); err != nil {
+				return newError("print: %s", err)
+			}
+			return NULL
+		},
+	},
+	"printf": {
+		Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments. got=%d, want at least 1",
+					len(args))
+			}
+			format, _, err := stringArgs("printf", args[0])
+			if err != nil {
+				return err
+			}
+			formatted, err := formatObjects(format, args[1:])
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(rt.Writer(), formatted); err != nil {
+				return newError("printf: %s", err)
+			}
+			return NULL
+		},
+	},
+}
+
+func init() {
+	for name, builtin := range Builtins {
+		builtin.Name = name
+	}
+}
+
+// stringArgs unwraps one or two STRING arguments of the builtin called name.
+func stringArgs(name string, args ...object.Object) (string, string, *object.Error) {
+	values := [2]string{}
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return "", "", newError("argument to `%s` must be STRING, got %s",
+				name, arg.Type())
+		}
+		values[i] = str.Value
+	}
+	return values[0], values[1], nil
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, v := range values {
+		elements[i] = &object.String{Value: v}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func arrayArg(name string, arg object.Object) (*object.Array, *object.Error) {
+	arr, ok := arg.(*object.Array)
+	if !ok {
+		return nil, newError("argument to `%s` must be ARRAY, got %s",
+			name, arg.Type())
+	}
+	return arr, nil
+}
+
+func integerArgs(name string, args ...object.Object) ([]int64, *object.Error) {
+	values := make([]int64, len(args))
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return nil, newError("argument to `%s` must be INTEGER, got %s",
+				name, arg.Type())
+		}
+		values[i] = integer.Value
+	}
+	return values, nil
+}
+
+// newArray copies elements into a fresh array, followed by extra.
+func newArray(elements []object.Object, extra ...object.Object) *object.Array {
+	copied := make([]object.Object, 0, len(elements)+len(extra))
+	copied = append(copied, elements...)
+	copied = append(copied, extra...)
+	return &object.Array{Elements: copied}
+}
+
+func arrayIndex(arr *object.Array, target object.Object) int {
+	for i, e := range arr.Elements {
+		if objectsEqual(e, target) {
+			return i
+		}
+	}
+	return -1
+}
+
+// callbackArgs unwraps the (array, function) arguments of the
+// higher-order builtin called name.
+func callbackArgs(name string, args []object.Object) (*object.Array, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments. got=%d, want=2",
+			len(args))
+	}
+	arr, err := arrayArg(name, args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	switch args[1].(type) {
+	case *object.Function, *object.Builtin:
+		return arr, args[1], nil
+	default:
+		return nil, nil, newError("argument to `%s` must be FUNCTION, got %s",
+			name, args[1].Type())
+	}
+}
+
+func sortByKey(rt object.Runtime, arr *object.Array, fn object.Object) object.Object {
+	keys := make([]object.Object, len(arr.Elements))
+	for i, element := range arr.Elements {
+		key := rt.Apply(fn, element)
+		if isError(key) {
+			return key
+		}
+		if key.Type() != object.INTEGER_OBJ && key.Type() != object.STRING_OBJ {
+			return newError("sortBy key must be INTEGER or STRING, got %s", key.Type())
+		}
+		if i > 0 && key.Type() != keys[0].Type() {
+			return newError("sortBy keys must have the same type, got %s and %s",
+				keys[0].Type(), key.Type())
+		}
+		keys[i] = key
+	}
+	indexes := make([]int, len(keys))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		switch left := keys[indexes[i]].(type) {
+		case *object.Integer:
+			return left.Value < keys[indexes[j]].(*object.Integer).Value
+		default:
+			return left.Inspect() < keys[indexes[j]].Inspect()
+		}
+	})
+	sorted := make([]object.Object, len(indexes))
+	for i, idx := range indexes {
+		sorted[i] = arr.Elements[idx]
+	}
+	return &object.Array{Elements: sorted}
+}
+
+// formatObjects expands the verbs in format with args: %d takes an
+// INTEGER, %s a STRING, %v any value and %% is a literal percent sign.
+func formatObjects(format string, args []object.Object) (string, *object.Error) {
+	var out strings.Builder
+	next := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i == len(format) {
+			return "", newError("format ends with a lone %%")
+		}
+		verb := format[i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if next >= len(args) {
+			return "", newError("missing argument for %%%c", verb)
+		}
+		arg := args[next]
+		next++
+
+		switch verb {
+		case 'd':
+			if arg.Type() != object.INTEGER_OBJ {
+				return "", newError("%%d expects INTEGER, got %s", arg.Type())
+			}
+		case 's':
+			if arg.Type() != object.STRING_OBJ {
+				return "", newError("%%s expects STRING, got %s", arg.Type())
+			}
+		case 'v':
+		default:
+			return "", newError("unknown format verb %%%c", verb)
+		}
+		out.WriteString(arg.Inspect())
+	}
+
+	if next < len(args) {
+		return "", newError("too many arguments for format: got=%d, want=%d",
+			len(args), next)
+	}
+	return out.String(), nil
+}
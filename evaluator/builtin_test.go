@@ -0,0 +1,276 @@
+package evaluator
+
+import (
+	"bytes"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+// builtinError marks an expected value as the message of an *object.Error.
+type builtinError string
+
+// inspected marks an expected value as the Inspect() output of the result.
+type inspected string
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`split("a,b,c", ",")`, []string{"a", "b", "c"}},
+		{`split("abc", "")`, []string{"a", "b", "c"}},
+		{`split("abc", ",")`, []string{"abc"}},
+		{`split("abc")`, builtinError("wrong number of arguments. got=1, want=2")},
+		{`split(1, ",")`, builtinError("argument to `split` must be STRING, got INTEGER")},
+		{`join(split("a b c", " "), "-")`, "a-b-c"},
+		{`join([1, "two", true], ", ")`, "1, two, true"},
+		{`join([], ",")`, ""},
+		{`join("abc", ",")`, builtinError("argument to `join` must be ARRAY, got STRING")},
+		{`join(["a"], 1)`, builtinError("argument to `join` must be STRING, got INTEGER")},
+		{`trim("  hello  ")`, "hello"},
+		{`trim(true)`, builtinError("argument to `trim` must be STRING, got BOOLEAN")},
+		{`upper("Hello")`, "HELLO"},
+		{`lower("Hello")`, "hello"},
+		{`lower("a", "b")`, builtinError("wrong number of arguments. got=2, want=1")},
+		{`contains("monkey", "key")`, true},
+		{`contains("monkey", "ape")`, false},
+		{`contains("monkey", 1)`, builtinError("argument to `contains` must be STRING, got INTEGER")},
+		{`startsWith("monkey", "mon")`, true},
+		{`startsWith("monkey", "key")`, false},
+		{`endsWith("monkey", "key")`, true},
+		{`endsWith("monkey", "mon")`, false},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`replace("abc", "x", "y")`, "abc"},
+		{`replace("abc", "a", 1)`, builtinError("argument to `replace` must be STRING, got INTEGER")},
+		{`replace("abc", "a")`, builtinError("wrong number of arguments. got=2, want=3")},
+		{`indexOf("monkey", "key")`, 3},
+		{`indexOf("monkey", "ape")`, -1},
+		{`indexOf("héllo", "l")`, 2},
+		{`len("héllo")`, 5},
+		{`let s = "héllo"; substr(s, indexOf(s, "l"))`, "llo"},
+		{`let s = "日本語"; substr(s, len(s) - 1)`, "語"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`repeat("ab", -1)`, builtinError("negative repeat count: -1")},
+		{`repeat("ab", 4611686018427387904)`, builtinError("repeat count too large: 4611686018427387904")},
+		{`repeat("", 4611686018427387904)`, ""},
+		{`repeat("ab", "3")`, builtinError("argument to `repeat` must be INTEGER, got STRING")},
+		{`substr("monkey", 3)`, "key"},
+		{`substr("monkey", 0, 3)`, "mon"},
+		{`substr("monkey", 2, 100)`, "nkey"},
+		{`substr("monkey", 6)`, ""},
+		{`substr("héllo", 1, 2)`, "él"},
+		{`substr("日本語", 2)`, "語"},
+		{`substr("日本語", 4)`, builtinError("substr start out of range: 4")},
+		{`substr("monkey", 1, 9223372036854775807)`, "onkey"},
+		{`substr("monkey", 7)`, builtinError("substr start out of range: 7")},
+		{`substr("monkey", 1, -1)`, builtinError("negative substr length: -1")},
+		{`substr("monkey")`, builtinError("wrong number of arguments. got=1, want=2 or 3")},
+		{`chars("abc")`, []string{"a", "b", "c"}},
+		{`chars("")`, []string{}},
+		{`chars(["a"])`, builtinError("argument to `chars` must be STRING, got ARRAY")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len([1, 2, 3])`, 3},
+		{`len([])`, 0},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, nil},
+		{`first(1)`, builtinError("argument to `first` must be ARRAY, got INTEGER")},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, nil},
+		{`last([1], [2])`, builtinError("wrong number of arguments. got=2, want=1")},
+		{`rest([1, 2, 3])`, inspected("[2, 3]")},
+		{`rest([1])`, inspected("[]")},
+		{`rest([])`, nil},
+		{`push([], 1)`, inspected("[1]")},
+		{`let a = [1]; let b = push(a, 2); [a, b]`, inspected("[[1], [1, 2]]")},
+		{`push(1, 1)`, builtinError("argument to `push` must be ARRAY, got INTEGER")},
+		{`pop([1, 2, 3])`, inspected("[1, 2]")},
+		{`let a = [1, 2]; pop(a); a`, inspected("[1, 2]")},
+		{`pop([])`, nil},
+		{`slice([1, 2, 3, 4], 1)`, inspected("[2, 3, 4]")},
+		{`slice([1, 2, 3, 4], 1, 3)`, inspected("[2, 3]")},
+		{`slice([1, 2, 3, 4], 2, 2)`, inspected("[]")},
+		{`slice([1, 2], 1, 5)`, builtinError("slice bounds out of range: [1:5] with length 2")},
+		{`slice([1, 2], 2, 1)`, builtinError("slice bounds out of range: [2:1] with length 2")},
+		{`slice([1, 2], "1")`, builtinError("argument to `slice` must be INTEGER, got STRING")},
+		{`concat([1], [2, 3], [])`, inspected("[1, 2, 3]")},
+		{`concat()`, inspected("[]")},
+		{`concat([1], 2)`, builtinError("argument to `concat` must be ARRAY, got INTEGER")},
+		{`reverse([1, 2, 3])`, inspected("[3, 2, 1]")},
+		{`reverse([])`, inspected("[]")},
+		{`contains([1, "two", [3]], "two")`, true},
+		{`contains([1, "two", [3]], [3])`, true},
+		{`contains([1, 2], 3)`, false},
+		{`indexOf([1, 2, 3], 3)`, 2},
+		{`indexOf([1, 2, 3], "3")`, -1},
+		{`range(3)`, inspected("[0, 1, 2]")},
+		{`range(2, 5)`, inspected("[2, 3, 4]")},
+		{`range(0, 10, 3)`, inspected("[0, 3, 6, 9]")},
+		{`range(3, 0, -1)`, inspected("[3, 2, 1]")},
+		{`range(3, 0)`, inspected("[]")},
+		{`range(0, 3, 0)`, builtinError("range step must not be zero")},
+		{`range(9223372036854775806, 9223372036854775807, 2)`, inspected("[9223372036854775806]")},
+		{`range(-9223372036854775807, 9223372036854775807)`, builtinError("range too large: 18446744073709551614 elements")},
+		{`range()`, builtinError("wrong number of arguments. got=0, want=1 to 3")},
+		{`range("a")`, builtinError("argument to `range` must be INTEGER, got STRING")},
+		{`zip([1, 2, 3], ["a", "b"])`, inspected("[[1, a], [2, b]]")},
+		{`zip([], [1])`, inspected("[]")},
+		{`zip([1], "a")`, builtinError("argument to `zip` must be ARRAY, got STRING")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, inspected("[2, 4, 6]")},
+		{`map([], fn(x) { x })`, inspected("[]")},
+		{`map(["a", "b"], upper)`, inspected("[A, B]")},
+		{`let n = 10; map([1, 2], fn(x) { x + n })`, inspected("[11, 12]")},
+		{`map([1, true], fn(x) { -x })`, builtinError("unknown operator: -BOOLEAN")},
+		{`map([1], fn(x, y) { x })`, builtinError("wrong number of arguments: want=2, got=1")},
+		{`map([1], 1)`, builtinError("argument to `map` must be FUNCTION, got INTEGER")},
+		{`map(1, fn(x) { x })`, builtinError("argument to `map` must be ARRAY, got INTEGER")},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, inspected("[3, 4]")},
+		{`filter([1, 2, 3], fn(x) { false })`, inspected("[]")},
+		{`reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })`, 10},
+		{`reduce([], 5, fn(acc, x) { acc + x })`, 5},
+		{`reduce(["a", "b"], "", fn(acc, x) { acc + x })`, "ab"},
+		{`reduce([1], fn(acc, x) { acc })`, builtinError("wrong number of arguments. got=2, want=3")},
+		{`sortBy([3, 1, 2], fn(x) { x })`, inspected("[1, 2, 3]")},
+		{`sortBy(["bb", "a", "ccc"], len)`, inspected("[a, bb, ccc]")},
+		{`sortBy(["b", "c", "a"], fn(x) { x })`, inspected("[a, b, c]")},
+		{`sortBy([1, 3, 2], fn(a, b) { a > b })`, inspected("[3, 2, 1]")},
+		{`sortBy([[2, "x"], [1, "y"], [2, "z"]], fn(p) { p[0] })`, inspected("[[1, y], [2, x], [2, z]]")},
+		{`sortBy([1, "a"], fn(x) { x })`, builtinError("sortBy keys must have the same type, got INTEGER and STRING")},
+		{`sortBy([true], fn(x) { x })`, builtinError("sortBy key must be INTEGER or STRING, got BOOLEAN")},
+		{`sortBy([1, 2], fn(a, b) { a + true })`, builtinError("type mismatch: INTEGER + BOOLEAN")},
+		{`let a = [2, 1]; sortBy(a, fn(x) { x }); a`, inspected("[2, 1]")},
+		{`any([1, 2, 3], fn(x) { x > 2 })`, true},
+		{`any([1, 2, 3], fn(x) { x > 3 })`, false},
+		{`any([], fn(x) { true })`, false},
+		{`all([1, 2, 3], fn(x) { x > 0 })`, true},
+		{`all([1, 2, 3], fn(x) { x > 1 })`, false},
+		{`all([], fn(x) { false })`, true},
+		{`find([1, 2, 3], fn(x) { x > 1 })`, 2},
+		{`find([1, 2, 3], fn(x) { x > 3 })`, nil},
+		{`groupBy([1, 2, 3, 4, 5], fn(x) { x - (x / 2) * 2 })`, inspected("{0: [2, 4], 1: [1, 3, 5]}")},
+		{`groupBy(["apple", "avocado", "banana"], fn(s) { substr(s, 0, 1) })`, inspected("{a: [apple, avocado], b: [banana]}")},
+		{`groupBy([1], fn(x) { [x] })`, builtinError("unusable as hash key: ARRAY")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestOutputBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		output   string
+		expected interface{}
+	}{
+		{`puts("hello")`, "hello\n", nil},
+		{`puts(1, [2, 3], true)`, "1\n[2, 3]\ntrue\n", nil},
+		{`puts()`, "", nil},
+		{`print("a", 1); print("b")`, "a 1b", nil},
+		{`printf("%s has %d items (%v)", "cart", 3, [1])`, "cart has 3 items ([1])", nil},
+		{`printf("100%%")`, "100%", nil},
+		{`let f = fn(x) { puts(x); x * 2 }; f(f(1))`, "1\n2\n", 4},
+		{`printf("%d", "3")`, "", builtinError("%d expects INTEGER, got STRING")},
+		{`printf("%s", 3)`, "", builtinError("%s expects STRING, got INTEGER")},
+		{`printf("%d %d", 1)`, "", builtinError("missing argument for %d")},
+		{`printf("%d", 1, 2)`, "", builtinError("too many arguments for format: got=2, want=1")},
+		{`printf("%x", 1)`, "", builtinError("unknown format verb %x")},
+		{`printf("50%")`, "", builtinError("format ends with a lone %")},
+		{`printf(1)`, "", builtinError("argument to `printf` must be STRING, got INTEGER")},
+		{`printf()`, "", builtinError("wrong number of arguments. got=0, want at least 1")},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := New()
+		e.Out = &out
+
+		program := parser.MakeNewParser(lexer.New(tt.input)).ParseProgram()
+		evaluated := e.Eval(program, object.NewEnvironment(nil))
+
+		testBuiltinResult(t, tt.input, evaluated, tt.expected)
+		if out.String() != tt.output {
+			t.Errorf("%s: wrong output. expected=%q, got=%q", tt.input, tt.output, out.String())
+		}
+	}
+}
+
+func testBuiltinResult(t *testing.T, input string, obj object.Object, expected interface{}) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		testIntegerObject(t, obj, int64(expected))
+	case bool:
+		testBooleanObject(t, obj, expected)
+	case string:
+		str, ok := obj.(*object.String)
+		if !ok {
+			t.Errorf("%s: object is not String. got=%T (%+v)", input, obj, obj)
+			return
+		}
+		if str.Value != expected {
+			t.Errorf("%s: wrong value. expected=%q, got=%q", input, expected, str.Value)
+		}
+	case []string:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			t.Errorf("%s: object is not Array. got=%T (%+v)", input, obj, obj)
+			return
+		}
+		if len(arr.Elements) != len(expected) {
+			t.Errorf("%s: wrong number of elements. expected=%d, got=%d",
+				input, len(expected), len(arr.Elements))
+			return
+		}
+		for i, e := range expected {
+			str, ok := arr.Elements[i].(*object.String)
+			if !ok || str.Value != e {
+				t.Errorf("%s: wrong element %d. expected=%q, got=%s",
+					input, i, e, arr.Elements[i].Inspect())
+			}
+		}
+	case inspected:
+		if obj == nil || obj.Inspect() != string(expected) {
+			t.Errorf("%s: wrong result. expected=%s, got=%+v", input, expected, obj)
+		}
+	case nil:
+		testNullObject(t, obj)
+	case builtinError:
+		errObj, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("%s: object is not Error. got=%T (%+v)", input, obj, obj)
+			return
+		}
+		if errObj.Message != string(expected) {
+			t.Errorf("%s: wrong error message. expected=%q, got=%q", input, expected, errObj.Message)
+		}
+	default:
+		t.Fatalf("%s: expected type not handled: %T", input, expected)
+	}
+}
@@ -0,0 +1,169 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+	"strings"
+)
+
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
+	return e.EvalContext(context.Background(), node, env)
+}
+
+// EvalContext evaluates node in env as one run: the step count starts
+// from zero and evaluation is aborted with a TIMEOUT_ERR or CANCELED_ERR
+// error once ctx is done, or with a STEP_LIMIT_ERR error once MaxSteps
+// is exceeded.
+func (e *Evaluator) EvalContext(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	return e.run(ctx, func() object.Object {
+		return e.eval(node, env)
+	})
+}
+
+// ApplyContext is the host-side counterpart of Apply and starts a run
+// like EvalContext does.
+func (e *Evaluator) ApplyContext(ctx context.Context, fn object.Object, args ...object.Object) object.Object {
+	return e.run(ctx, func() object.Object {
+		return e.Apply(fn, args...)
+	})
+}
+
+// Context returns the context of the current run, so builtins that block
+// can give up when it is done.
+func (e *Evaluator) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// run starts a new run unless one is already in progress, in which case a
+// host callback re-entering the evaluator shares the limits of that run.
+func (e *Evaluator) run(ctx context.Context, fn func() object.Object) object.Object {
+	if e.running {
+		return fn()
+	}
+	e.ctx = ctx
+	e.steps = 0
+	e.live, e.peak = 0, 0
+	e.stack = e.stack[:0]
+	e.depth = 0
+	e.running = true
+	defer func() {
+		e.running = false
+	}()
+	return fn()
+}
+
+func (e *Evaluator) step() *object.Error {
+	e.steps++
+	if e.MaxSteps > 0 && e.steps > e.MaxSteps {
+		return &object.Error{Kind: object.STEP_LIMIT_ERR,
+			Message: fmt.Sprintf("step limit of %d exceeded", e.MaxSteps)}
+	}
+	if e.ctx == nil {
+		return nil
+	}
+	select {
+	case <-e.ctx.Done():
+		return contextError(e.ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// contextError turns the error of a done context into the error that
+// aborts the run.
+func contextError(err error) *object.Error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &object.Error{Kind: object.TIMEOUT_ERR,
+			Message: "evaluation timed out"}
+	}
+	return &object.Error{Kind: object.CANCELED_ERR,
+		Message: "evaluation canceled"}
+}
+
+func functionName(f *object.Function) string {
+	if f.Name == "" {
+		return "<anonymous>"
+	}
+	return f.Name
+}
+
+func builtinName(b *object.Builtin) string {
+	if b.Name == "" {
+		return "<builtin>"
+	}
+	return b.Name
+}
+
+// push adds f to the call stack and returns the func that pops it.
+func (e *Evaluator) push(f frame) func() {
+	e.stack = append(e.stack, f)
+	if f.function {
+		e.depth++
+	}
+	return func() {
+		e.stack = e.stack[:len(e.stack)-1]
+		if f.function {
+			e.depth--
+		}
+	}
+}
+
+// trace describes the call stack innermost first, with each frame
+// positioned where it is evaluating: tok for the innermost one, and the
+// call site of the frame above it for the others.
+func (e *Evaluator) trace(tok token.Token) []object.Frame {
+	frames := make([]object.Frame, 0, len(e.stack)+1)
+	for i := len(e.stack); i >= 0; i-- {
+		name := "<main>"
+		if i > 0 {
+			name = e.stack[i-1].name
+		}
+		pos := tok
+		if i < len(e.stack) {
+			pos = e.stack[i].call
+		}
+		frames = append(frames, object.Frame{Function: name, Line: pos.Line, Column: pos.Column})
+	}
+	return frames
+}
+
+// maxListedFrames caps how many frames a call depth error lists.
+const maxListedFrames = 10
+
+// callDepthError lists the Monkey functions on the call stack innermost
+// first, folding repeated frames so that deep recursion stays readable.
+func (e *Evaluator) callDepthError() *object.Error {
+	names := []string{}
+	for _, f := range e.stack {
+		if f.function {
+			names = append(names, f.name)
+		}
+	}
+	frames := []string{}
+	for i := len(names) - 1; i >= 0; {
+		if len(frames) == maxListedFrames {
+			frames = append(frames, fmt.Sprintf("... (%d more)", i+1))
+			break
+		}
+		j := i
+		for j >= 0 && names[j] == names[i] {
+			j--
+		}
+		if count := i - j; count > 1 {
+			frames = append(frames, fmt.Sprintf("%s (x%d)", names[i], count))
+		} else {
+			frames = append(frames, names[i])
+		}
+		i = j
+	}
+	return &object.Error{Kind: object.CALL_DEPTH_ERR,
+		Message: fmt.Sprintf("maximum call depth exceeded (%d): %s",
+			e.MaxCallDepth, strings.Join(frames, " <- "))}
+}
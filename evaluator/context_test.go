@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+	"testing"
+	"time"
+)
+
+const busyLoop = `map(range(3000), fn(i) { map(range(3000), fn(j) { i * j }) })`
+
+func testEvalWith(e *Evaluator, ctx context.Context, input string) object.Object {
+	program := parser.MakeNewParser(lexer.New(input)).ParseProgram()
+	return e.EvalContext(ctx, program, object.NewEnvironment(nil))
+}
+
+func testAbortError(t *testing.T, obj object.Object, kind object.ErrorKind, message string) {
+	t.Helper()
+	errObj, ok := obj.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", obj, obj)
+	}
+	if errObj.Kind != kind {
+		t.Errorf("wrong error kind. expected=%q, got=%q", kind, errObj.Kind)
+	}
+	if errObj.Message != message {
+		t.Errorf("wrong error message. expected=%q, got=%q", message, errObj.Message)
+	}
+}
+
+func TestEvalContextTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	evaluated := testEvalWith(New(), ctx, busyLoop)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("evaluation was not stopped in time. took=%s", elapsed)
+	}
+	testAbortError(t, evaluated, object.TIMEOUT_ERR, "evaluation timed out")
+}
+
+func TestEvalContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	evaluated := EvalContext(ctx, parser.MakeNewParser(lexer.New("1 + 1")).ParseProgram(),
+		object.NewEnvironment(nil))
+	testAbortError(t, evaluated, object.CANCELED_ERR, "evaluation canceled")
+}
+
+func TestMaxSteps(t *testing.T) {
+	e := New()
+	e.MaxSteps = 1000
+
+	evaluated := testEvalWith(e, context.Background(), busyLoop)
+	testAbortError(t, evaluated, object.STEP_LIMIT_ERR, "step limit of 1000 exceeded")
+
+	// The count starts over for every run.
+	for i := 0; i < 3; i++ {
+		evaluated = testEvalWith(e, context.Background(), "let f = fn(x) { x * 2 }; f(f(f(1)))")
+		testIntegerObject(t, evaluated, 8)
+	}
+}
+
+func TestStepLimitIsNotMaskedByBuiltins(t *testing.T) {
+	e := New()
+	e.MaxSteps = 50
+
+	tests := []string{
+		`filter(range(100), fn(x) { true })`,
+		`reduce(range(100), 0, fn(acc, x) { acc + x })`,
+		`sortBy(range(100), fn(a, b) { a > b })`,
+		`groupBy(range(100), fn(x) { x })`,
+	}
+
+	for _, input := range tests {
+		evaluated := testEvalWith(e, context.Background(), input)
+		testAbortError(t, evaluated, object.STEP_LIMIT_ERR, "step limit of 50 exceeded")
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let countdown = fn(n) { if (n == 0) { 0 } else { 1 + countdown(n - 1) } }; countdown(1000)`,
+			"maximum call depth exceeded (100): countdown (x100)",
+		},
+		{
+			`let inner = fn(n) { inner(n + 1) };
+			let outer = fn() { inner(0) };
+			let main = fn() { outer() };
+			main()`,
+			"maximum call depth exceeded (100): inner (x98) <- outer <- main",
+		},
+		{
+			`let ping = fn(n) { pong(n) }; let pong = fn(n) { ping(n) }; ping(0)`,
+			"maximum call depth exceeded (100): " + strings.Repeat("pong <- ping <- ", 5) + "... (90 more)",
+		},
+		{
+			`let f = fn(n) { map([n], fn(x) { f(x) }) }; f(0)`,
+			"maximum call depth exceeded (100): " + strings.Repeat("<anonymous> <- f <- ", 5) + "... (90 more)",
+		},
+	}
+
+	for _, tt := range tests {
+		e := New()
+		e.MaxCallDepth = 100
+		evaluated := testEvalWith(e, context.Background(), tt.input)
+		testAbortError(t, evaluated, object.CALL_DEPTH_ERR, tt.expected)
+	}
+}
+
+func TestDefaultMaxCallDepth(t *testing.T) {
+	// Without a limit this recursion would overflow the Go stack and
+	// crash the test binary.
+	input := `let f = fn(n) { f(n + 1) }; f(0)`
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Kind != object.CALL_DEPTH_ERR {
+		t.Fatalf("expected call depth error. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	// The evaluator is usable again after the error.
+	testIntegerObject(t, testEval(`let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(5000)`), 0)
+}
@@ -0,0 +1,71 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLetDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let [a, b] = [1, 2]; a + b`, 3},
+		{`let [a, ...rest] = [1, 2, 3]; rest`, inspected("[2, 3]")},
+		{`let [a, ...rest] = [1]; rest`, inspected("[]")},
+		{`let [_, [x, y]] = [0, [1, 2]]; x * 10 + y`, 12},
+		{`let {x, y} = {"x": 1, "y": 2, "z": 3}; [x, y]`, inspected("[1, 2]")},
+		{`let {name: n, tags: [first, ...others]} = {"name": "m", "tags": ["a", "b"]}; [n, first, others]`,
+			inspected(`[m, a, [b]]`)},
+		{`let [a, 2] = [1, 2]; a`, 1},
+		{`let [a, b] = [1, 2, 3]; a`,
+			builtinError("cannot destructure [a, b]: expected 2 elements, got 3")},
+		{`let [a, b, ...c] = [1]; a`,
+			builtinError("cannot destructure [a, b, ...c]: expected at least 2 elements, got 1")},
+		{`let [a] = 1; a`, builtinError("cannot destructure [a]: expected ARRAY, got INTEGER")},
+		{`let {x, y} = {"x": 1}; x`, builtinError("cannot destructure {x: x, y: y}: missing key y")},
+		{`let {p: [a, b]} = {"p": [1]}; a`,
+			builtinError("cannot destructure {p: [a, b]}: key p: expected 2 elements, got 1")},
+		{`let [[a], b] = [1, 2]; a`,
+			builtinError("cannot destructure [[a], b]: element 0: expected ARRAY, got INTEGER")},
+		{`let [a, 2] = [1, 3]; a`, builtinError("cannot destructure [a, 2]: element 1: expected 2, got 3")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestParameterDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let add = fn([a, b]) { a + b }; add([1, 2])`, 3},
+		{`let norm = fn({x, y}, scale) { (x + y) * scale }; norm({"x": 1, "y": 2}, 10)`, 30},
+		{`map([[1, 2], [3, 4]], fn([a, b]) { a * b })`, inspected("[2, 12]")},
+		{`let head = fn([h, ...t]) { h }; head([7, 8, 9])`, 7},
+		{`let f = fn([a, b]) { a }; f([1])`,
+			builtinError("cannot destructure [a, b]: expected 2 elements, got 1")},
+		{`let f = fn([a, b]) { a }; f`, inspected("fn([a, b]) {\na\n}")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMatchRestPattern(t *testing.T) {
+	input := `let sum = fn(xs) { match (xs) { [] => 0, [h, ...t] => h + sum(t) } }; sum([1, 2, 3, 4])`
+	testBuiltinResult(t, input, testEval(input), 10)
+}
+
+func TestExportDestructuring(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"pair.mk": `export let [first, second] = [1, 2];`,
+	})
+	e := New()
+	e.ModulePath = []string{dir}
+	input := `import "pair"; pair.first + pair.second`
+	testBuiltinResult(t, input, testEvalWith(e, context.Background(), input), 3)
+}
@@ -1,9 +1,15 @@
 package evaluator
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"math/rand"
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
+	"os"
 )
 
 var (
@@ -16,6 +22,16 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// at records where an error that does not know its position yet was
+// raised, along with the stack trace leading there.
+func (e *Evaluator) at(tok token.Token, obj object.Object) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Line == 0 {
+		err.Line, err.Column = tok.Line, tok.Column
+		err.Stack = e.trace(tok)
+	}
+	return obj
+}
+
 func isError(obj object.Object) bool{
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -23,123 +39,368 @@ func isError(obj object.Object) bool{
 	return false
 }
 
-func applyFunction(ce *ast.CallExpression, env *object.Environment) object.Object{
-	
-	
-	function := Eval(ce.Function, env)
+// Evaluator walks the AST and produces objects. It is handed to builtins
+// as their object.Runtime, so they can call back into Monkey code.
+type Evaluator struct {
+	Builtins map[string]*object.Builtin
+	// Out receives the output of puts, print and printf.
+	Out io.Writer
+	// MaxSteps bounds the number of nodes a single run may evaluate.
+	// Zero means no limit.
+	MaxSteps int
+	// MaxMemory bounds the approximate number of bytes of strings, arrays,
+	// hashes and environments a single run may have in use at once. Zero
+	// means no limit.
+	MaxMemory int64
+	// MaxCallDepth bounds how deeply Monkey functions may call each other
+	// before evaluation fails instead of overflowing the Go stack. Zero
+	// means no limit.
+	MaxCallDepth int
+	// ModulePath lists the directories searched by import after the
+	// importing module's own directory. Empty means the working directory.
+	ModulePath []string
+	// Clock is the time source of the time module. Nil means the system
+	// clock.
+	Clock Clock
+	// Rand is the source of the random module, seeded from the current
+	// time when nil.
+	Rand *rand.Rand
+
+	ctx       context.Context
+	steps     int
+	// live is the memory the run is using, and peak the most it has used.
+	live      int64
+	peak      int64
+	running   bool
+	// stack holds the functions, builtins and modules being evaluated.
+	stack []frame
+	// depth counts the Monkey function frames on the stack.
+	depth int
+	// modules caches imported modules by absolute path; loading holds the
+	// chain of modules being imported to detect cycles.
+	modules map[string]*object.Module
+	loading []string
+}
+
+type frame struct {
+	name string
+	// call is the call expression, or the import, that started the
+	// frame. It is the zero Token for calls made by builtins or the host.
+	call token.Token
+	// function is set for Monkey functions, which MaxCallDepth limits.
+	function bool
+}
+
+// DefaultMaxCallDepth stays well clear of Go's stack limit.
+const DefaultMaxCallDepth = 10000
+
+func New() *Evaluator {
+	return &Evaluator{Builtins: Builtins, Out: os.Stdout, MaxCallDepth: DefaultMaxCallDepth}
+}
+
+func (e *Evaluator) Writer() io.Writer {
+	return e.Out
+}
+
+// Eval evaluates node with a fresh Evaluator using the default Builtins.
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New().Eval(node, env)
+}
+
+// EvalContext is like Eval but stops with an error once ctx is done.
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	return New().EvalContext(ctx, node, env)
+}
+
+func (e *Evaluator) evalCallExpression(ce *ast.CallExpression, env *object.Environment) object.Object{
+	quoted := IsQuote(ce, func(string) bool {
+		_, ok := env.Lookup(ce.Function.(*ast.Identifier))
+		return ok
+	})
+	if quoted {
+		return e.at(ce.Token, e.quote(ce, env))
+	}
+	function := e.eval(ce.Function, env)
+	if isError(function) {
+		return function
+	}
+	args := e.evalExpressions(ce.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	return e.at(ce.Token, e.call(ce.Token, function, args...))
+}
+
+// Apply calls a Monkey function or a builtin with already evaluated args.
+func (e *Evaluator) Apply(function object.Object, args ...object.Object) object.Object {
+	return e.call(token.Token{}, function, args...)
+}
 
-	switch function.(type) {
+// call is Apply for a call made at the call expression site.
+func (e *Evaluator) call(site token.Token, function object.Object, args ...object.Object) object.Object {
+	if err := e.step(); err != nil {
+		return err
+	}
+	switch f := function.(type) {
 	case *object.Function:
-		f := function.(*object.Function)
-		newEnv := object.NewEnvironment(f.Env)
-		for idx, arg := range ce.Arguments {
-			val := Eval(arg, env)
-			if isError(val) {
-				return val
+		if len(args) != len(f.Parameters) {
+			return newError("wrong number of arguments: want=%d, got=%d",
+				len(f.Parameters), len(args))
+		}
+		if e.MaxCallDepth > 0 && e.depth >= e.MaxCallDepth {
+			return e.callDepthError()
+		}
+		mark, modules := e.live, len(e.modules)
+		if err := e.Allocate(envOverhead); err != nil {
+			return err
+		}
+		defer e.push(frame{name: functionName(f), call: site, function: true})()
+		newEnv := object.NewScopedEnvironment(f.Env, f.Scope)
+		for idx, param := range f.Parameters {
+			if err := e.destructure(param, args[idx], newEnv); err != nil {
+				return err
 			}
-			newEnv.Set(f.Parameters[idx].String(), val)
 		}
-		evaluated := Eval(f.Body, newEnv)
+		// The body shares newEnv with the parameters rather than getting a
+		// scope of its own, since newEnv is fresh for every call anyway.
+		evaluated := e.evalBlockStatement(f.Body.Statements, newEnv)
 		if returnValue, ok := evaluated.(*object.ReturnValue); ok {
-			return returnValue.Value
+			evaluated = returnValue.Value
 		}
+		e.release(mark, modules, evaluated)
 		return evaluated
 	case *object.Builtin:
-		f := function.(*object.Builtin)
-		var args = []object.Object{}
-		for _, arg := range ce.Arguments {
-			val := Eval(arg, env)
-			if isError(val) {
-				return val
-			}
-			args = append(args , val)
-		}
-		return f.Fn(args...)
+		defer e.push(frame{name: builtinName(f), call: site})()
+		return f.Fn(e, args...)
 	default:
 		return newError("not a function: %s", function.Type())
 	}
 }
 
-func Eval(node ast.Node, env *object.Environment) object.Object{
+func (e *Evaluator) eval(node ast.Node, env *object.Environment) object.Object{
+	if err := e.step(); err != nil {
+		return err
+	}
+
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node.Statements, env)
+		Resolve(node)
+		return e.evalProgram(node.Statements, env)
 	case *ast.FunctionLiteral:
-		return &object.Function{Parameters: node.Parameters,
-		Body: node.Body, Env: env}
+		return &object.Function{Parameters: node.Parameters, Body: node.Body,
+		Scope: node.Scope, Env: env}
+	case *ast.MacroLiteral:
+		return e.at(node.Token, newError("macros can only be defined by top-level let statements"))
 	case *ast.CallExpression:
-		return applyFunction(node, env)		
+		return e.evalCallExpression(node, env)		
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
+		val := e.eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			if err := e.destructure(node.Pattern, val, env); err != nil {
+				return e.at(node.Token, err)
+			}
+			return nil
+		}
+		if err := e.Allocate(bindingSize); err != nil {
+			return err
+		}
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			fn.Name = node.Name.Value
+		}
+		env.Bind(node.Name, val)
+	case *ast.ImportStatement:
+		return e.at(node.Token, e.evalImportStatement(node, env))
+	case *ast.ExportStatement:
+		return e.eval(node.Statement, env)
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return e.at(node.Token, e.evalIdentifier(node, env))
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.eval(node.Expression, env)
 	case *ast.BooleanExpression:
 		return nativeBooleanObject(node.Value) 
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return track(e, e.evalInterpolatedString(node.Parts, env))
+	case *ast.ArrayLiteral:
+		elements := e.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return track(e, &object.Array{Elements: elements})
+	case *ast.HashLiteral:
+		return e.at(node.Token, track(e, e.evalHashLiteral(node, env)))
+	case *ast.IndexExpression:
+		left := e.eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := e.eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return e.at(node.Token, evalIndexExpression(left, index))
+	case *ast.PropertyExpression:
+		left := e.eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		return e.at(node.Token, evalPropertyExpression(left, node.Property.Value))
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := e.eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return e.at(node.Token, evalPrefixExpression(node.Operator, right))
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
-		right := Eval(node.Right, env)
+		left := e.eval(node.Left, env)
+		right := e.eval(node.Right, env)
 		if isError(left) {
 			return left
 		}
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return e.at(node.Token, track(e, evalInfixExpression(node.Operator, left, right)))
 	case *ast.BlockStatement:
-		return evalBlockStatement(node.Statements, env)
+		if declaresBindings(node) {
+			if err := e.Allocate(envOverhead); err != nil {
+				return err
+			}
+			env = object.NewScopedEnvironment(env, node.Scope)
+		}
+		return e.evalBlockStatement(node.Statements, env)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
+	case *ast.TryExpression:
+		return e.evalTryExpression(node, env)
+	case *ast.MatchExpression:
+		return e.evalMatchExpression(node, env)
+	case *ast.ThrowStatement:
+		return e.at(node.Token, e.evalThrowStatement(node, env))
 	case *ast.ReturnStatement:
-		return evalReturnExpression(node.ReturnValue, env)
+		return e.evalReturnExpression(node.ReturnValue, env)
 	}
 	return nil
 }
 
 
-func evalIdentifier(ident *ast.Identifier, env *object.Environment) object.Object{
-	val, ok := env.Get(ident.Value)
+func (e *Evaluator) evalIdentifier(ident *ast.Identifier, env *object.Environment) object.Object{
+	val, ok := env.Lookup(ident)
 	if ok {
 		return val
 	}
-	if builtin, ok := Builtins[ident.Value]; ok {
+	if builtin, ok := e.Builtins[ident.Value]; ok {
 		return builtin
 	}
+	if _, ok := stdModules[ident.Value]; ok {
+		return e.stdModule(ident.Value)
+	}
 	return newError("identifier not found: %s", ident.Value)
 }
 
-func evalReturnExpression(node ast.Node, env *object.Environment) object.Object{
-	returnValue := Eval(node, env)
+func (e *Evaluator) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+	for _, exp := range exps {
+		evaluated := e.eval(exp, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	elements := array.(*object.Array).Elements
+	idx := index.(*object.Integer).Value
+	if idx < 0 || idx >= int64(len(elements)) {
+		return NULL
+	}
+	return elements[idx]
+}
+
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+	value, ok := hash.(*object.Hash).Get(key)
+	if !ok {
+		return NULL
+	}
+	return value
+}
+
+func (e *Evaluator) evalHashLiteral(hl *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+	for i, keyNode := range hl.Keys {
+		key := e.eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+		value := e.eval(hl.Values[i], env)
+		if isError(value) {
+			return value
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash
+}
+
+func (e *Evaluator) evalInterpolatedString(parts []ast.Expression, env *object.Environment) object.Object {
+	var out bytes.Buffer
+	for _, part := range parts {
+		val := e.eval(part, env)
+		if isError(val) {
+			return val
+		}
+		if val != nil {
+			out.WriteString(val.Inspect())
+		}
+	}
+	return &object.String{Value: out.String()}
+}
+
+func (e *Evaluator) evalReturnExpression(node ast.Node, env *object.Environment) object.Object{
+	returnValue := e.eval(node, env)
 	if isError(returnValue) {
 		return returnValue
 	}
 	return &object.ReturnValue{Value: returnValue}
 }
 
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
+func (e *Evaluator) evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := e.eval(ie.Condition, env)
 	if isError(condition) {
 		return condition
 	}
 	if (isTruthy(condition)) {
-		return Eval(ie.Consequence, env)
+		return e.eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return e.eval(ie.Alternative, env)
 	} else {
 		return NULL
 	}
@@ -166,6 +427,8 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	case left.Type() == object.STRING_OBJ &&
 			right.Type() == object.STRING_OBJ:
 			 return evalStringInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBooleanObject(left == right)
 	case operator == "!=":
@@ -202,6 +465,9 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	case "*":
 		return &object.Integer{Value: leftValue * rightValue}
 	case "/":
+		if rightValue == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: leftValue / rightValue}
 	case ">":
 		return nativeBooleanObject(leftValue > rightValue)
@@ -218,6 +484,46 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 }
 
 
+// isNumber reports whether obj is an INTEGER or a FLOAT. Arithmetic
+// mixing the two is done in floating point.
+func isNumber(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.INTEGER_OBJ || t == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	if integer, ok := obj.(*object.Integer); ok {
+		return float64(integer.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftValue := toFloat(left)
+	rightValue := toFloat(right)
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftValue + rightValue}
+	case "-":
+		return &object.Float{Value: leftValue - rightValue}
+	case "*":
+		return &object.Float{Value: leftValue * rightValue}
+	case "/":
+		return &object.Float{Value: leftValue / rightValue}
+	case ">":
+		return nativeBooleanObject(leftValue > rightValue)
+	case "<":
+		return nativeBooleanObject(leftValue < rightValue)
+	case "==":
+		return nativeBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBooleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(),
+			operator, right.Type())
+	}
+}
+
 func evalPrefixExpression(operator string, right object.Object) object.Object{
 	switch operator {
 	case "!":
@@ -230,6 +536,9 @@ func evalPrefixExpression(operator string, right object.Object) object.Object{
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if float, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -float.Value}
+	}
 	if right.Type() != object.INTEGER_OBJ {
 		return newError("unknown operator: -%s", right.Type())
 	}
@@ -250,6 +559,39 @@ func evalBangOperatorExpression(right object.Object) object.Object{
 	}
 }
 
+// objectsEqual compares values structurally, unlike the == operator on
+// non-number objects which compares identity. Numbers compare by value
+// whether they are INTEGER or FLOAT, as they do with ==.
+func objectsEqual(left, right object.Object) bool {
+	if isNumber(left) && isNumber(right) && left.Type() != right.Type() {
+		return toFloat(left) == toFloat(right)
+	}
+	if left.Type() != right.Type() {
+		return false
+	}
+	switch left := left.(type) {
+	case *object.Integer:
+		return left.Value == right.(*object.Integer).Value
+	case *object.Float:
+		return left.Value == right.(*object.Float).Value
+	case *object.String:
+		return left.Value == right.(*object.String).Value
+	case *object.Array:
+		other := right.(*object.Array)
+		if len(left.Elements) != len(other.Elements) {
+			return false
+		}
+		for i := range left.Elements {
+			if !objectsEqual(left.Elements[i], other.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
+
 func nativeBooleanObject(input bool) *object.Boolean{
 	if input {
 		return TRUE
@@ -257,10 +599,10 @@ func nativeBooleanObject(input bool) *object.Boolean{
 	return FALSE
 }
 
-func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object{
+func (e *Evaluator) evalProgram(stmts []ast.Statement, env *object.Environment) object.Object{
 	var result object.Object
 	for _, stmt := range stmts {
-		result = Eval(stmt, env)
+		result = e.eval(stmt, env)
 		
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -272,10 +614,23 @@ func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object{
 	return result
 }
 
-func evalBlockStatement(stmts []ast.Statement, env *object.Environment) object.Object{
+// declaresBindings reports whether block binds names of its own, in which
+// case it is evaluated in a child environment so they do not leak out.
+// Other blocks reuse the enclosing environment, which is cheaper.
+func declaresBindings(block *ast.BlockStatement) bool {
+	for _, stmt := range block.Statements {
+		switch stmt.(type) {
+		case *ast.LetStatement, *ast.ImportStatement:
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Evaluator) evalBlockStatement(stmts []ast.Statement, env *object.Environment) object.Object{
 	var result object.Object
 	for _, stmt := range stmts {
-		result = Eval(stmt, env)
+		result = e.eval(stmt, env)
 		
 		if result != nil {
 			rt := result.Type()
@@ -352,3 +352,258 @@ func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 	return true
 }
 
+func TestInterpolatedString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"plain"`, "plain"},
+		{`let name = "monkey"; "hello ${name}"`, "hello monkey"},
+		{`let n = 2; "you have ${n + 1} items"`, "you have 3 items"},
+		{`"${1}${2}"`, "12"},
+		{`"${true} and ${1 < 2}"`, "true and true"},
+		{`let f = fn(x) { "<${x}>" }; "[${f("in ${"ner"}")}]"`, "[<in ner>]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
+func TestInterpolatedStringError(t *testing.T) {
+	evaluated := testEval(`"a ${1 + true} b"`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error Message. got=%q", errObj.Message)
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	}
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+			continue
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`{"name": "Monkey"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`{[1]: 2}`, "unusable as hash key: ARRAY"},
+		{`1[0]`, "index operator not supported: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error Message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2.5", "2.5"},
+		{"-2.5", "-2.5"},
+		{"1.5 + 1.5", "3.0"},
+		{"1 + 0.5", "1.5"},
+		{"7 / 2.0", "3.5"},
+		{"0.1 * 3", "0.30000000000000004"},
+		{"1.0 / 0", "+Inf"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		float, ok := evaluated.(*object.Float)
+		if !ok {
+			t.Errorf("%s: object is not Float. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if float.Inspect() != tt.expected {
+			t.Errorf("%s: wrong value. expected=%s, got=%s", tt.input, tt.expected, float.Inspect())
+		}
+	}
+
+	comparisons := []struct {
+		input    string
+		expected bool
+	}{
+		{"1.5 < 2", true},
+		{"2 > 2.5", false},
+		{"2 == 2.0", true},
+		{"0.5 != 0.5", false},
+	}
+	for _, tt := range comparisons {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestElseIfExpressions(t *testing.T) {
+	classify := `let classify = fn(x) {
+		if (x < 0) { "negative" } else if (x == 0) { "zero" } else if (x < 10) { "small" } else { "large" }
+	};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{classify + `classify(-5)`, "negative"},
+		{classify + `classify(0)`, "zero"},
+		{classify + `classify(7)`, "small"},
+		{classify + `classify(70)`, "large"},
+		{`if (false) { 1 } else if (false) { 2 }`, nil},
+		{`if (false) { 1 } else if (true) { 2 } else { 3 }`, 2},
+		{`let f = fn(x) { if (x == 1) { return "one" } else if (x == 2) { return "two" }; "other" }; [f(1), f(2), f(3)]`,
+			[]string{"one", "two", "other"}},
+		{`if (false) { 1 } else if (1 + true) { 2 }`, builtinError("type mismatch: INTEGER + BOOLEAN")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestBlockScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let x = 1; if (true) { let x = 2; x }`, 2},
+		{`let x = 1; if (true) { let x = 2; }; x`, 1},
+		{`if (true) { let y = 2; }; y`, builtinError("identifier not found: y")},
+		{`let x = 1; if (true) { let x = x + 1; x }`, 2},
+		{`let x = 1; let f = fn() { if (true) { let x = 10; x } }; [f(), x]`, inspected("[10, 1]")},
+		{`let f = fn(x) { if (true) { let x = x * 2; }; x }; f(3)`, 3},
+		{`let counter = if (true) { let n = 41; fn() { n + 1 } }; counter()`, 42},
+		{`let fs = map([1, 2, 3], fn(i) { if (true) { let j = i * 10; fn() { j } } }); map(fs, fn(f) { f() })`,
+			inspected("[10, 20, 30]")},
+		{`let x = 1; try { let x = 2; throw "x" } catch (e) { x }`, 1},
+		{`let x = 1; if (true) { if (true) { let x = 3; }; x }`, 1},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
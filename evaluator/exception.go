@@ -0,0 +1,93 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// evalTryExpression catches errors raised in the try block, except those
+// that abort the run. The finally block runs whenever the try or catch
+// block finishes without aborting, and a return or error from it takes
+// precedence over their result.
+func (e *Evaluator) evalTryExpression(te *ast.TryExpression, env *object.Environment) object.Object {
+	result := e.eval(te.Block, env)
+
+	if err, ok := result.(*object.Error); ok && !err.IsAbort() && te.Catch != nil {
+		catchEnv := env
+		if te.Param != nil {
+			if allocErr := e.Allocate(envOverhead + bindingSize); allocErr != nil {
+				return allocErr
+			}
+			catchEnv = object.NewScopedEnvironment(env, te.CatchScope)
+			catchEnv.Bind(te.Param, &object.ErrorValue{Err: err})
+		}
+		result = e.eval(te.Catch, catchEnv)
+	}
+
+	if te.Finally != nil && !isAbort(result) {
+		finally := e.eval(te.Finally, env)
+		if finally != nil {
+			if rt := finally.Type(); rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return finally
+			}
+		}
+	}
+	return result
+}
+
+// evalThrowStatement raises the thrown value as an error. Throwing a
+// caught error raises it again unchanged.
+func (e *Evaluator) evalThrowStatement(ts *ast.ThrowStatement, env *object.Environment) object.Object {
+	val := e.eval(ts.Value, env)
+	if isError(val) {
+		return val
+	}
+	if val == nil {
+		// An empty block, such as the body of fn() {}, has no value.
+		val = NULL
+	}
+	switch val := val.(type) {
+	case *object.ErrorValue:
+		return val.Err
+	case *object.String:
+		return &object.Error{Message: val.Value, Kind: object.THROWN_ERR, Value: val}
+	default:
+		return &object.Error{Message: val.Inspect(), Kind: object.THROWN_ERR, Value: val}
+	}
+}
+
+func isAbort(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	return ok && err.IsAbort()
+}
+
+// errorProperty exposes a caught error to scripts. Ordinary runtime
+// errors have the kind RUNTIME.
+func errorProperty(err *object.Error, name string) object.Object {
+	switch name {
+	case "message":
+		return &object.String{Value: err.Message}
+	case "kind":
+		if err.Kind == "" {
+			return &object.String{Value: "RUNTIME"}
+		}
+		return &object.String{Value: string(err.Kind)}
+	case "line":
+		return &object.Integer{Value: int64(err.Line)}
+	case "column":
+		return &object.Integer{Value: int64(err.Column)}
+	case "stack":
+		frames := make([]string, len(err.Stack))
+		for i, f := range err.Stack {
+			frames[i] = f.String()
+		}
+		return stringArray(frames)
+	case "value":
+		if err.Value == nil {
+			return NULL
+		}
+		return err.Value
+	default:
+		return newError("error has no property %s", name)
+	}
+}
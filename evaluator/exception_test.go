@@ -0,0 +1,183 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"monkey/object"
+	"testing"
+	"time"
+)
+
+func TestTryCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { 1 + true } catch (e) { e.message }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`try { 1 + true } catch (e) { e.kind }`, "RUNTIME"},
+		{`try { throw "boom" } catch (e) { [e.message, e.kind] }`, []string{"boom", "THROWN"}},
+		{`try { throw {"code": 7} } catch (e) { e.value.code }`, 7},
+		{`try { throw {"code": 7} } catch (e) { e.message }`, "{code: 7}"},
+		{`try { missing } catch { "recovered" }`, "recovered"},
+		{`let x = try { len(1) } catch (e) { -1 }; x + 1`, 0},
+		{`let f = fn() { throw "inner" }; try { f() } catch (e) { e.message }`, "inner"},
+		{`try { map([1, 2], fn(x) { throw "in callback" }) } catch (e) { e.message }`, "in callback"},
+		{`try { try { throw "a" } catch (e) { throw e } } catch (e) { e.message }`, "a"},
+		{`try { try { throw "a" } finally { 1 } } catch (e) { e.message }`, "a"},
+		{`try { throw "a" } catch (e) { e.nope }`, builtinError("error has no property nope")},
+		{`throw "uncaught"`, builtinError("uncaught")},
+		{`throw 1 + true`, builtinError("type mismatch: INTEGER + BOOLEAN")},
+		{`let e = try { throw "x" } catch (err) { err }; e.message`, "x"},
+		{`try { throw fn() {}() } catch (e) { [e.message, e.value] }`, inspected("[null, null]")},
+		{`throw fn() {}()`, builtinError("null")},
+		{`try { 1 / 0 } catch (e) { [e.message, e.kind] }`, []string{"division by zero", "RUNTIME"}},
+		{`let f = fn(n) { 10 / n }; f(0)`, builtinError("division by zero")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTryFinally(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		output   string
+	}{
+		{`try { puts("try"); 1 } finally { puts("finally"); 2 }`, 1, "try\nfinally\n"},
+		{`try { throw "x" } catch (e) { puts("catch") } finally { puts("finally") }`, nil, "catch\nfinally\n"},
+		{`let f = fn() { try { return 1 } finally { puts("cleanup") }; 2 }; f()`, 1, "cleanup\n"},
+		{`let f = fn() { try { return 1 } finally { return 2 } }; f()`, 2, ""},
+		{`try { 1 } finally { throw "late" }`, builtinError("late"), ""},
+		{`try { throw "x" } finally { puts("finally") }`, builtinError("x"), "finally\n"},
+	}
+
+	for _, tt := range tests {
+		e := New()
+		var out bytes.Buffer
+		e.Out = &out
+		evaluated := testEvalWith(e, context.Background(), tt.input)
+		testBuiltinResult(t, tt.input, evaluated, tt.expected)
+		if out.String() != tt.output {
+			t.Errorf("%s: wrong output. expected=%q, got=%q", tt.input, tt.output, out.String())
+		}
+	}
+}
+
+func TestAbortsAreNotCatchable(t *testing.T) {
+	e := New()
+	e.MaxSteps = 1000
+	var out bytes.Buffer
+	e.Out = &out
+	input := `try { ` + busyLoop + ` } catch (e) { puts("caught") } finally { puts("finally") }`
+	evaluated := testEvalWith(e, context.Background(), input)
+	testAbortError(t, evaluated, object.STEP_LIMIT_ERR, "step limit of 1000 exceeded")
+	if out.Len() != 0 {
+		t.Errorf("abort was caught. output=%q", out.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	evaluated = testEvalWith(New(), ctx, `try { `+busyLoop+` } catch (e) { 0 }`)
+	testAbortError(t, evaluated, object.TIMEOUT_ERR, "evaluation timed out")
+
+	e = New()
+	e.MaxMemory = 1 << 12
+	evaluated = testEvalWith(e, context.Background(), `try { repeat("x", 100000) } catch (e) { 0 }`)
+	testAbortError(t, evaluated, object.MEMORY_LIMIT_ERR, "memory limit of 4096 bytes exceeded")
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input        string
+		line, column int
+	}{
+		{"let x = 1;\nx + true", 2, 3},
+		{"let f = fn() {\n  missing\n};\nf()", 2, 3},
+		{"len(1, 2)", 1, 4},
+		{"[1][\"a\"]\n", 1, 4},
+		{"\n\n    throw \"x\"", 3, 5},
+		{"try { 1 + true } catch (e) { throw e }", 1, 9},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: object is not Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if err.Line != tt.line || err.Column != tt.column {
+			t.Errorf("%q: wrong position. expected=%d:%d, got=%d:%d",
+				tt.input, tt.line, tt.column, err.Line, err.Column)
+		}
+	}
+
+	testBuiltinResult(t, "line", testEval("try {\n  1 + true\n} catch (e) { [e.line, e.column] }"), inspected("[2, 5]"))
+}
+
+func TestStackTrace(t *testing.T) {
+	input := `let add = fn(a, b) {
+  a + b
+};
+let compute = fn(x) {
+  map([1, 2], fn(y) { add(x, y) })
+};
+compute("s");`
+
+	evaluated := testEval(input)
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := `ERROR: type mismatch: STRING + INTEGER
+    at add (line 2, column 5)
+    at <anonymous> (line 5, column 26)
+    at map
+    at compute (line 5, column 6)
+    at <main> (line 7, column 8)`
+	if err.StackTrace() != expected {
+		t.Errorf("wrong stack trace.\nexpected:\n%s\ngot:\n%s", expected, err.StackTrace())
+	}
+
+	testBuiltinResult(t, "stack", testEval(`let f = fn() { throw "x" }; try { f() } catch (e) { e.stack }`),
+		[]string{"f (line 1, column 16)", "<main> (line 1, column 36)"})
+}
+
+func TestStackTraceFoldsRecursion(t *testing.T) {
+	evaluated := testEval(`let down = fn(n) { if (n == 0) { 1 + true } else { down(n - 1) } }; down(50)`)
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := `ERROR: type mismatch: INTEGER + BOOLEAN
+    at down (line 1, column 36)
+    at down (line 1, column 56) (x50)
+    at <main> (line 1, column 73)`
+	if err.StackTrace() != expected {
+		t.Errorf("wrong stack trace.\nexpected:\n%s\ngot:\n%s", expected, err.StackTrace())
+	}
+}
+
+func TestStackTraceThroughModules(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib.mk":  "export let fail = fn() {\n  throw \"from lib\"\n};\n",
+		"boot.mk": "import \"lib\";\nlib.fail();\n",
+	})
+	e := New()
+	e.ModulePath = []string{dir}
+	evaluated := testEvalWith(e, context.Background(), `import "boot";`)
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := `ERROR: from lib
+    at fail (line 2, column 3)
+    at <module boot> (line 2, column 9)
+    at <main> (line 1, column 1)`
+	if err.StackTrace() != expected {
+		t.Errorf("wrong stack trace.\nexpected:\n%s\ngot:\n%s", expected, err.StackTrace())
+	}
+}
@@ -0,0 +1,250 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+	"reflect"
+	"strconv"
+)
+
+// IsQuote reports whether call is the quote special form rather than an
+// ordinary call: a call of the name quote, where bound reports that name
+// has no binding that would make it an ordinary function.
+func IsQuote(call *ast.CallExpression, bound func(name string) bool) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == "quote" && !bound(ident.Value)
+}
+
+// quote returns the code passed to quote() without evaluating it, after
+// replacing each unquote(x) in it with the code for the value of x.
+func (e *Evaluator) quote(ce *ast.CallExpression, env *object.Environment) object.Object {
+	if len(ce.Arguments) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(ce.Arguments))
+	}
+	var failed object.Object
+	node := ast.Modify(cloneNode(ce.Arguments[0]), func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || failed != nil || call.Function.TokenLiteral() != "unquote" {
+			return node
+		}
+		if len(call.Arguments) != 1 {
+			failed = e.at(call.Token, newError("wrong number of arguments. got=%d, want=1",
+				len(call.Arguments)))
+			return node
+		}
+		unquoted := e.eval(call.Arguments[0], env)
+		if isError(unquoted) {
+			failed = unquoted
+			return node
+		}
+		converted, err := objectToNode(unquoted, call.Token)
+		if err != nil {
+			failed = e.at(call.Token, err)
+			return node
+		}
+		return converted
+	})
+	if failed != nil {
+		return failed
+	}
+	return &object.Quote{Node: node}
+}
+
+// objectToNode turns an unquoted value back into code. The literals it
+// makes are positioned at tok, the unquote call they replace.
+func objectToNode(obj object.Object, tok token.Token) (ast.Expression, *object.Error) {
+	at := func(typ token.TokenType, literal string) token.Token {
+		return token.Token{Type: typ, Literal: literal, Line: tok.Line, Column: tok.Column}
+	}
+	switch obj := obj.(type) {
+	case *object.Quote:
+		expression, ok := obj.Node.(ast.Expression)
+		if !ok {
+			return nil, newError("cannot unquote %s", obj.Inspect())
+		}
+		// Each splice gets a copy, since the resolver annotates the
+		// nodes of each site with where their names are bound there.
+		return cloneNode(expression).(ast.Expression), nil
+	case *object.Integer:
+		return &ast.IntegerLiteral{Token: at(token.INT, strconv.FormatInt(obj.Value, 10)), Value: obj.Value}, nil
+	case *object.Float:
+		return &ast.FloatLiteral{Token: at(token.FLOAT, obj.Inspect()), Value: obj.Value}, nil
+	case *object.String:
+		return &ast.StringLiteral{Token: at(token.STRING, obj.Value), Value: obj.Value}, nil
+	case *object.Boolean:
+		if obj.Value {
+			return &ast.BooleanExpression{Token: at(token.TRUE, "true"), Value: true}, nil
+		}
+		return &ast.BooleanExpression{Token: at(token.FALSE, "false"), Value: false}, nil
+	case *object.Array:
+		array := &ast.ArrayLiteral{Token: at(token.LBRACKET, "[")}
+		for _, element := range obj.Elements {
+			node, err := objectToNode(element, tok)
+			if err != nil {
+				return nil, err
+			}
+			array.Elements = append(array.Elements, node)
+		}
+		return array, nil
+	case *object.Hash:
+		hash := &ast.HashLiteral{Token: at(token.LBRACE, "{")}
+		for _, pair := range obj.SortedPairs() {
+			key, err := objectToNode(pair.Key, tok)
+			if err != nil {
+				return nil, err
+			}
+			value, err := objectToNode(pair.Value, tok)
+			if err != nil {
+				return nil, err
+			}
+			hash.Keys = append(hash.Keys, key)
+			hash.Values = append(hash.Values, value)
+		}
+		return hash, nil
+	default:
+		return nil, newError("cannot unquote %s", obj.Type())
+	}
+}
+
+// cloneNode deep-copies node. Unquoting rewrites the copy, so code that
+// quotes runs the same way every time it runs.
+func cloneNode(node ast.Node) ast.Node {
+	return cloneValue(reflect.ValueOf(node)).Interface().(ast.Node)
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return v
+		}
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(v.Elem())
+		for i := 0; i < c.Elem().NumField(); i++ {
+			if field := c.Elem().Field(i); field.CanSet() {
+				field.Set(cloneValue(field))
+			}
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// DefineMacros binds the macros defined by top-level let statements in
+// program in env and removes those statements from program.
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	statements := program.Statements[:0]
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || let.Name == nil {
+			statements = append(statements, stmt)
+			continue
+		}
+		macro, ok := let.Value.(*ast.MacroLiteral)
+		if !ok {
+			statements = append(statements, stmt)
+			continue
+		}
+		env.Set(let.Name.Value, &object.Macro{Parameters: macro.Parameters, Body: macro.Body, Env: env})
+	}
+	program.Statements = statements
+}
+
+// ExpandMacros replaces each call to a macro bound in env with the code
+// the macro returns, given its arguments quoted.
+func (e *Evaluator) ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	return e.ExpandMacrosContext(context.Background(), program, env)
+}
+
+// ExpandMacrosContext is like ExpandMacros but runs the macros like
+// EvalContext runs code.
+func (e *Evaluator) ExpandMacrosContext(ctx context.Context, program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var failed *object.Error
+	e.run(ctx, func() object.Object {
+		program = ast.Modify(program, func(node ast.Node) ast.Node {
+			if failed != nil {
+				return node
+			}
+			call, ok := node.(*ast.CallExpression)
+			if !ok {
+				return node
+			}
+			ident, ok := call.Function.(*ast.Identifier)
+			if !ok {
+				return node
+			}
+			obj, ok := env.Get(ident.Value)
+			if !ok {
+				return node
+			}
+			macro, ok := obj.(*object.Macro)
+			if !ok {
+				return node
+			}
+			expanded, err := e.expandMacro(ident.Value, call, macro)
+			if err != nil {
+				failed = err
+				return node
+			}
+			return expanded
+		})
+		return nil
+	})
+	return program, failed
+}
+
+// expandMacro evaluates the body of macro with its parameters bound to
+// the quoted arguments of call.
+func (e *Evaluator) expandMacro(name string, call *ast.CallExpression, macro *object.Macro) (ast.Node, *object.Error) {
+	if len(call.Arguments) != len(macro.Parameters) {
+		err := newError("wrong number of arguments: want=%d, got=%d",
+			len(macro.Parameters), len(call.Arguments))
+		e.at(call.Token, err)
+		return nil, err
+	}
+	defer e.push(frame{name: name, call: call.Token, function: true})()
+	env := object.NewEnvironment(macro.Env)
+	for i, param := range macro.Parameters {
+		env.Set(param.Value, &object.Quote{Node: call.Arguments[i]})
+	}
+	evaluated := e.evalBlockStatement(macro.Body.Statements, env)
+	if returnValue, ok := evaluated.(*object.ReturnValue); ok {
+		evaluated = returnValue.Value
+	}
+	if err, ok := evaluated.(*object.Error); ok {
+		return nil, err
+	}
+	quote, ok := evaluated.(*object.Quote)
+	if !ok {
+		err := newError("macro %s must return a QUOTE, got %s", name, typeOf(evaluated))
+		e.at(call.Token, err)
+		return nil, err
+	}
+	return quote.Node, nil
+}
+
+func typeOf(obj object.Object) string {
+	if obj == nil {
+		return object.NULL_OBJ
+	}
+	return string(obj.Type())
+}
@@ -0,0 +1,235 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `(5 + 8)`},
+		{`quote(foobar)`, `foobar`},
+		{`quote(foobar + barfoo)`, `(foobar + barfoo)`},
+	}
+
+	for _, tt := range tests {
+		testQuoteObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote(4))`, `4`},
+		{`quote(unquote(4 + 4))`, `8`},
+		{`quote(8 + unquote(4 + 4))`, `(8 + 8)`},
+		{`quote(unquote(4 + 4) + 8)`, `(8 + 8)`},
+		{`let foobar = 8; quote(foobar)`, `foobar`},
+		{`let foobar = 8; quote(unquote(foobar))`, `8`},
+		{`quote(unquote(true))`, `true`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote(quote(4 + 4)))`, `(4 + 4)`},
+		{`let quotedInfix = quote(4 + 4); quote(unquote(4 + 4) + unquote(quotedInfix))`, `(8 + (4 + 4))`},
+		{`quote(unquote("a" + "b"))`, `ab`},
+		{`quote(unquote(1.5))`, `1.5`},
+		{`quote(len(unquote([1, 2])))`, `len([1, 2])`},
+		{`quote(unquote({"a": 1}))`, `{a: 1}`},
+		{`let f = fn(x) { quote(unquote(x)) }; f(1); f(2)`, `2`},
+	}
+
+	for _, tt := range tests {
+		testQuoteObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestQuoteErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`quote(1, 2)`, builtinError("wrong number of arguments. got=2, want=1")},
+		{`quote(unquote())`, builtinError("wrong number of arguments. got=0, want=1")},
+		{`quote(unquote(fn() { 1 }))`, builtinError("cannot unquote FUNCTION")},
+		{`quote(unquote(1 + true))`, builtinError("type mismatch: INTEGER + BOOLEAN")},
+		{`unquote(1)`, builtinError("identifier not found: unquote")},
+		{`let f = fn() { macro(x) { x } }; f()`,
+			builtinError("macros can only be defined by top-level let statements")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestQuoteCanBeRebound(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let quote = fn(x) { x * 2 }; quote(21)`, 42},
+		{`let f = fn(quote) { quote(21) }; f(fn(x) { x + 1 })`, 22},
+		{`let f = fn() { let quote = fn(x) { -x }; quote(1 + 2) }; f()`, -3},
+		{`let f = fn(x) { match (x) { quote => quote(4) } }; f(len)`, builtinError("argument to len not supported, got INTEGER")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+	testQuoteObject(t, `let f = fn(x) { quote(x) }; f(1)`, testEval(`let f = fn(x) { quote(x) }; f(1)`), `x`)
+}
+
+func testQuoteObject(t *testing.T, input string, obj object.Object, expected string) {
+	t.Helper()
+	quote, ok := obj.(*object.Quote)
+	if !ok {
+		t.Errorf("%s: object is not Quote. got=%T (%+v)", input, obj, obj)
+		return
+	}
+	if quote.Node == nil {
+		t.Errorf("%s: quote.Node is nil", input)
+		return
+	}
+	if quote.Node.String() != expected {
+		t.Errorf("%s: wrong quoted code. expected=%q, got=%q", input, expected, quote.Node.String())
+	}
+}
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := object.NewEnvironment(nil)
+	program := testParseProgram(input)
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+	if _, ok := env.Get("number"); ok {
+		t.Fatalf("number should not be defined")
+	}
+	if _, ok := env.Get("function"); ok {
+		t.Fatalf("function should not be defined")
+	}
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment.")
+	}
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+	if len(macro.Parameters) != 2 || macro.Parameters[0].String() != "x" || macro.Parameters[1].String() != "y" {
+		t.Fatalf("wrong macro parameters. got=%v", macro.Parameters)
+	}
+	if macro.Body.String() != "(x + y)" {
+		t.Fatalf("wrong macro body. got=%q", macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infixExpression = macro() { quote(1 + 2); };
+			infixExpression();`,
+			`(1 + 2)`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+			reverse(2 + 2, 10 - 5);`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+			unless(10 > 5, puts("not greater"), puts("greater"));`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			`let twice = macro(x) { quote([unquote(x), unquote(x)]) };
+			let f = fn() { twice(len("ab")) };`,
+			`let f = fn() { [len("ab"), len("ab")] };`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment(nil)
+		DefineMacros(program, env)
+		expanded, err := New().ExpandMacros(program, env)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.input, err.Message)
+			continue
+		}
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		line     int
+	}{
+		{"let m = macro(x) { x };\nm(1, 2)", "wrong number of arguments: want=1, got=2", 2},
+		{"let m = macro() { 1 };\nm()", "macro m must return a QUOTE, got INTEGER", 2},
+		{"let m = macro() {\n  1 + true\n};\nm()", "type mismatch: INTEGER + BOOLEAN", 2},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment(nil)
+		DefineMacros(program, env)
+		_, err := New().ExpandMacros(program, env)
+		if err == nil {
+			t.Errorf("%q: expected an error", tt.input)
+			continue
+		}
+		if err.Message != tt.expected || err.Line != tt.line {
+			t.Errorf("%q: wrong error. expected=%q at line %d, got=%q at line %d",
+				tt.input, tt.expected, tt.line, err.Message, err.Line)
+		}
+	}
+}
+
+func TestMacrosInModules(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib.mk": `let square = macro(x) { quote(unquote(x) * unquote(x)) };
+export let nine = square(3);`,
+	})
+	e := New()
+	e.ModulePath = []string{dir}
+	input := `import "lib"; lib.nine`
+	program := testParseProgram(input)
+	testBuiltinResult(t, input, e.Eval(program, object.NewEnvironment(nil)), 9)
+}
+
+func testParseProgram(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.MakeNewParser(l)
+	return p.ParseProgram()
+}
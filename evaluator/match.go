@@ -0,0 +1,183 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+)
+
+// evalMatchExpression evaluates the body of the first arm whose pattern
+// matches the subject and whose guard, if any, holds. Each arm binds its
+// names in an environment of its own.
+func (e *Evaluator) evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := e.eval(me.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range me.Arms {
+		if err := e.Allocate(envOverhead); err != nil {
+			return err
+		}
+		armEnv := object.NewScopedEnvironment(env, arm.Scope)
+		mismatch, err := e.matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if mismatch != "" {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := e.eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return e.eval(arm.Body, armEnv)
+	}
+	return e.at(me.Token, newError("non-exhaustive match: no arm matches %s", subject.Inspect()))
+}
+
+// matchPattern binds the names in pattern in env as it matches value
+// against it. When value does not match, it returns what is wrong with it
+// instead of the empty string.
+func (e *Evaluator) matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (string, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return "", nil
+	case *ast.BindingPattern:
+		if err := e.Allocate(bindingSize); err != nil {
+			return "", err
+		}
+		env.Bind(pattern.Name, value)
+		return "", nil
+	case *ast.LiteralPattern:
+		literal := e.eval(pattern.Value, env)
+		if err, ok := literal.(*object.Error); ok {
+			return "", err
+		}
+		if !objectsEqual(literal, value) {
+			return fmt.Sprintf("expected %s, got %s", literal.Inspect(), value.Inspect()), nil
+		}
+		return "", nil
+	case *ast.AlternativePattern:
+		// Each alternative binds its names in a scratch environment so
+		// that one which fails part way leaves nothing behind.
+		for _, alternative := range pattern.Alternatives {
+			scratch := env.Sibling()
+			mismatch, err := e.matchPattern(alternative, value, scratch)
+			if err != nil {
+				return "", err
+			}
+			if mismatch == "" {
+				env.Adopt(scratch)
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("%s matches none of %s", value.Inspect(), pattern.String()), nil
+	case *ast.ArrayPattern:
+		arr, ok := value.(*object.Array)
+		if !ok {
+			return fmt.Sprintf("expected ARRAY, got %s", value.Type()), nil
+		}
+		if pattern.Rest != nil && len(arr.Elements) < len(pattern.Elements) {
+			return fmt.Sprintf("expected at least %d elements, got %d",
+				len(pattern.Elements), len(arr.Elements)), nil
+		}
+		if pattern.Rest == nil && len(arr.Elements) != len(pattern.Elements) {
+			return fmt.Sprintf("expected %d elements, got %d",
+				len(pattern.Elements), len(arr.Elements)), nil
+		}
+		for i, element := range pattern.Elements {
+			mismatch, err := e.matchPattern(element, arr.Elements[i], env)
+			if err != nil {
+				return "", err
+			}
+			if mismatch != "" {
+				return fmt.Sprintf("element %d: %s", i, mismatch), nil
+			}
+		}
+		if pattern.Rest != nil {
+			rest := make([]object.Object, len(arr.Elements)-len(pattern.Elements))
+			copy(rest, arr.Elements[len(pattern.Elements):])
+			obj := &object.Array{Elements: rest}
+			if err := e.Allocate(bindingSize + arraySize(int64(len(rest)))); err != nil {
+				return "", err
+			}
+			env.Bind(pattern.Rest, obj)
+		}
+		return "", nil
+	case *ast.HashPattern:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return fmt.Sprintf("expected HASH, got %s", value.Type()), nil
+		}
+		for i, keyNode := range pattern.Keys {
+			key := e.eval(keyNode, env)
+			if err, ok := key.(*object.Error); ok {
+				return "", err
+			}
+			v, ok := hash.Get(key.(object.Hashable))
+			if !ok {
+				return fmt.Sprintf("missing key %s", key.Inspect()), nil
+			}
+			mismatch, err := e.matchPattern(pattern.Values[i], v, env)
+			if err != nil {
+				return "", err
+			}
+			if mismatch != "" {
+				return fmt.Sprintf("key %s: %s", key.Inspect(), mismatch), nil
+			}
+		}
+		return "", nil
+	default:
+		return "", newError("unknown pattern: %s", pattern.String())
+	}
+}
+
+// destructure binds the names in pattern to the matching parts of value,
+// failing when value has a different shape.
+func (e *Evaluator) destructure(pattern ast.Pattern, value object.Object, env *object.Environment) *object.Error {
+	mismatch, err := e.matchPattern(pattern, value, env)
+	if err != nil {
+		return err
+	}
+	if mismatch != "" {
+		return newError("cannot destructure %s: %s", pattern.String(), mismatch)
+	}
+	return nil
+}
+
+// patternNames lists the names pattern binds, in order.
+func patternNames(pattern ast.Pattern) []string {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		return []string{pattern.Name.Value}
+	case *ast.AlternativePattern:
+		names := []string{}
+		for _, alternative := range pattern.Alternatives {
+			names = append(names, patternNames(alternative)...)
+		}
+		return names
+	case *ast.ArrayPattern:
+		names := []string{}
+		for _, element := range pattern.Elements {
+			names = append(names, patternNames(element)...)
+		}
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest.Value)
+		}
+		return names
+	case *ast.HashPattern:
+		names := []string{}
+		for _, value := range pattern.Values {
+			names = append(names, patternNames(value)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
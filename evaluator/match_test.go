@@ -0,0 +1,88 @@
+package evaluator
+
+import "testing"
+
+func TestMatchExpression(t *testing.T) {
+	describe := `let describe = fn(x) {
+		match (x) {
+			0 => "zero",
+			-1 => "minus one",
+			"a" | "b" => "early letter",
+			true => "yes",
+			[] => "empty",
+			[a] => "one element: ${a}",
+			[a, b] if a == b => "pair of ${a}",
+			[a, [b, c]] => a + b + c,
+			{kind: "point", x: px, y} => px * y,
+			{name} => "hello ${name}",
+			n if n == 1000 => "big",
+			_ => "something else",
+		}
+	};`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{describe + `describe(0)`, "zero"},
+		{describe + `describe(-1)`, "minus one"},
+		{describe + `describe("b")`, "early letter"},
+		{describe + `describe(true)`, "yes"},
+		{describe + `describe(false)`, "something else"},
+		{describe + `describe([])`, "empty"},
+		{describe + `describe([7])`, "one element: 7"},
+		{describe + `describe([3, 3])`, "pair of 3"},
+		{describe + `describe([3, 4])`, "something else"},
+		{describe + `describe([1, [2, 3]])`, 6},
+		{describe + `describe({"kind": "point", "x": 3, "y": 4, "z": 0})`, 12},
+		{describe + `describe({"kind": "line", "x": 3, "y": 4})`, "something else"},
+		{describe + `describe({"name": "monkey"})`, "hello monkey"},
+		{describe + `describe(1000)`, "big"},
+		{describe + `describe(5)`, "something else"},
+		{`match (2.5) { 2.5 => "float", _ => "other" }`, "float"},
+		{`match (1) { 1.0 => "one", _ => "other" }`, "one"},
+		{`match (2.0) { 1 | 2 => "small", _ => "other" }`, "small"},
+		{`match ([1, 2]) { [b, c] | [c, b, _] => b - c }`, -1},
+		{`match ([1, 2]) { [a, 3] | [b, c] => a, _ => "none" }`, builtinError("identifier not found: a")},
+		{`match ([1, 2]) { [a, 3] | [b, c] => b + c, _ => "none" }`, 3},
+		{`match ([1, [2]]) { [x, [3] | [y]] | [x, y] => y }`, 2},
+		{`match (3) { n => { let doubled = n * 2; doubled + 1 } }`, 7},
+		{`let x = match (5) { n => n }; x`, 5},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMatchBindingsAreScopedToArms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let n = 1; match ([2, 3]) { [n, m] if m > 5 => n, [_, m] => n + m }`, 4},
+		{`match (1) { x => x }; x`, builtinError("identifier not found: x")},
+		{`let f = fn(v) { match (v) { [x] => fn() { x } } }; f([9])()`, 9},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match (3) { 1 => "one", 2 => "two" }`, builtinError("non-exhaustive match: no arm matches 3")},
+		{`match ([1, 2]) { [a] => a }`, builtinError("non-exhaustive match: no arm matches [1, 2]")},
+		{`match (1 + true) { _ => 1 }`, builtinError("type mismatch: INTEGER + BOOLEAN")},
+		{`match (1) { x if x + true => 1 }`, builtinError("type mismatch: INTEGER + BOOLEAN")},
+		{`try { match (3) { 1 => 1 } } catch (e) { e.message }`, "non-exhaustive match: no arm matches 3"},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
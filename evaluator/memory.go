@@ -0,0 +1,131 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"monkey/object"
+)
+
+// Rough sizes, in bytes, used to account for what a script allocates.
+// They only need to be in the right ballpark for quotas to be useful.
+const (
+	objectOverhead = 16
+	elementSize    = 16
+	pairSize       = 64
+	bindingSize    = 48
+	envOverhead    = 64
+)
+
+func stringSize(length int64) int64 {
+	return objectOverhead + length
+}
+
+// repeatedSize is the size of a string of length bytes repeated count
+// times, or math.MaxInt64 if that does not fit in an int64, so that an
+// overflowing request is always over quota.
+func repeatedSize(length, count int64) int64 {
+	if length > 0 && count > (math.MaxInt64-objectOverhead)/length {
+		return math.MaxInt64
+	}
+	return stringSize(length * count)
+}
+
+func arraySize(length int64) int64 {
+	return objectOverhead + length*elementSize
+}
+
+func hashSize(pairs int64) int64 {
+	return objectOverhead + pairs*pairSize
+}
+
+// objectSize is the size of obj itself, not counting the objects it
+// refers to, which were accounted for when they were created.
+func objectSize(obj object.Object) int64 {
+	switch obj := obj.(type) {
+	case *object.String:
+		return stringSize(int64(len(obj.Value)))
+	case *object.Array:
+		return arraySize(int64(len(obj.Elements)))
+	case *object.Hash:
+		return hashSize(int64(len(obj.Pairs)))
+	default:
+		return 0
+	}
+}
+
+// Allocate accounts for size bytes about to be allocated by the current
+// run and fails with a MEMORY_LIMIT_ERR error once the memory in use
+// exceeds MaxMemory.
+func (e *Evaluator) Allocate(size int64) *object.Error {
+	if size > math.MaxInt64-e.live {
+		e.live = math.MaxInt64
+	} else {
+		e.live += size
+	}
+	if e.live > e.peak {
+		e.peak = e.live
+	}
+	if e.MaxMemory > 0 && e.live > e.MaxMemory {
+		return &object.Error{Kind: object.MEMORY_LIMIT_ERR,
+			Message: fmt.Sprintf("memory limit of %d bytes exceeded", e.MaxMemory)}
+	}
+	return nil
+}
+
+// release gives back what a call allocated since live was mark, once it
+// has returned result, if nothing the call allocated can still be in use.
+// A call leaves nothing behind but its result and the modules it imports,
+// so that is the case when it imported none and result refers to no other
+// objects. Otherwise everything stays accounted for, which overestimates
+// the memory in use but never underestimates it.
+func (e *Evaluator) release(mark int64, modules int, result object.Object) {
+	if len(e.modules) != modules {
+		return
+	}
+	var size int64
+	switch result := result.(type) {
+	case nil, *object.Integer, *object.Float, *object.Boolean, *object.NULL:
+	case *object.String:
+		size = stringSize(int64(len(result.Value)))
+	default:
+		return
+	}
+	if mark+size < e.live {
+		e.live = mark + size
+	}
+}
+
+// PeakMemory returns roughly how many bytes of strings, arrays, hashes
+// and environments the last run had in use at most. Memory allocated by
+// calls that have returned is counted as released when the call returned
+// a number, boolean, null or string and imported no module.
+func (e *Evaluator) PeakMemory() int64 {
+	return e.peak
+}
+
+// track accounts for a freshly created obj and returns it, or the error
+// if the memory limit was exceeded.
+func track(rt object.Runtime, obj object.Object) object.Object {
+	if isError(obj) {
+		return obj
+	}
+	if err := rt.Allocate(objectSize(obj)); err != nil {
+		return err
+	}
+	return obj
+}
+
+// rangeLength is how many elements range(start, end, step) produces.
+// It works in uint64 so that extreme bounds cannot wrap around.
+func rangeLength(start, end, step int64) uint64 {
+	var distance, stride uint64
+	switch {
+	case step > 0 && start < end:
+		distance, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && start > end:
+		distance, stride = uint64(start)-uint64(end), -uint64(step)
+	default:
+		return 0
+	}
+	return (distance-1)/stride + 1
+}
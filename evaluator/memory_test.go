@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"testing"
+)
+
+func TestMaxMemory(t *testing.T) {
+	tests := []string{
+		`let s = "x"; let grow = fn(s, n) { if (n == 0) { s } else { grow(s + s, n - 1) } }; grow(s, 40)`,
+		`repeat("x", 1000000000)`,
+		`range(1000000000)`,
+		`range(-4611686018427387904, 4611686018427387904, 32)`,
+		`map(range(100000), fn(x) { [x, x] })`,
+		`reduce(range(100000), [], fn(acc, x) { push(acc, x) })`,
+		`let h = fn(n) { if (n == 0) { {} } else { {n: h(n - 1)} } }; h(100000)`,
+		`let deep = fn(n) { if (n == 0) { 0 } else { deep(n - 1) } }; deep(100000)`,
+		`"${repeat("ab", 1000)}${repeat("cd", 1000)}"`,
+	}
+
+	for _, input := range tests {
+		e := New()
+		e.MaxMemory = 1 << 12
+		evaluated := testEvalWith(e, context.Background(), input)
+		testAbortError(t, evaluated, object.MEMORY_LIMIT_ERR, "memory limit of 4096 bytes exceeded")
+		if e.PeakMemory() <= e.MaxMemory {
+			t.Errorf("%s: peak memory %d not above limit", input, e.PeakMemory())
+		}
+	}
+}
+
+func TestMemoryOfReturnedCallsIsReleased(t *testing.T) {
+	// Every call allocates, but none of it is in use once it returns,
+	// so the run stays within a limit smaller than its total.
+	e := New()
+	e.MaxMemory = 1 << 12
+	input := `reduce(range(100), 0, fn(acc, x) { let s = "${x}" + "abcdefghij"; acc + len(s) })`
+	testIntegerObject(t, testEvalWith(e, context.Background(), input), 1190)
+	if e.PeakMemory() <= 0 || e.PeakMemory() > e.MaxMemory {
+		t.Errorf("unexpected peak memory %d", e.PeakMemory())
+	}
+
+	// What a returned closure or array refers to stays in use.
+	e = New()
+	e.MaxMemory = 1 << 12
+	input = `map(range(100), fn(x) { let s = "${x}" + "abcdefghij"; [s] })`
+	testAbortError(t, testEvalWith(e, context.Background(), input), object.MEMORY_LIMIT_ERR, "memory limit of 4096 bytes exceeded")
+}
+
+func TestImpossibleRepeatIsNotCharged(t *testing.T) {
+	e := New()
+	e.MaxMemory = 1 << 12
+	evaluated := testEvalWith(e, context.Background(), `repeat("ab", 4611686018427387904)`)
+	testBuiltinResult(t, "repeat", evaluated, builtinError("repeat count too large: 4611686018427387904"))
+	if e.PeakMemory() > e.MaxMemory {
+		t.Errorf("quota charged for impossible size. peak memory=%d", e.PeakMemory())
+	}
+}
+
+func TestPeakMemory(t *testing.T) {
+	e := New()
+	e.MaxMemory = 1 << 20
+
+	small := testEvalWith(e, context.Background(), `let a = "abc"; len(a)`)
+	testIntegerObject(t, small, 3)
+	smallPeak := e.PeakMemory()
+	if smallPeak <= 0 {
+		t.Fatalf("peak memory not reported. got=%d", smallPeak)
+	}
+
+	large := testEvalWith(e, context.Background(), `len(map(range(1000), fn(x) { "n" + "${x}" }))`)
+	testIntegerObject(t, large, 1000)
+	if e.PeakMemory() <= smallPeak {
+		t.Errorf("peak memory of larger run %d not above %d", e.PeakMemory(), smallPeak)
+	}
+
+	// Each run is accounted for separately.
+	testEvalWith(e, context.Background(), `let a = "abc"; len(a)`)
+	if e.PeakMemory() != smallPeak {
+		t.Errorf("peak memory not reset between runs. expected=%d, got=%d", smallPeak, e.PeakMemory())
+	}
+}
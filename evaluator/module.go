@@ -0,0 +1,173 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleExt is appended to import paths that have no extension.
+const ModuleExt = ".mk"
+
+func (e *Evaluator) evalImportStatement(is *ast.ImportStatement, env *object.Environment) object.Object {
+	name := moduleName(is.Path)
+	if is.Alias != nil {
+		name = is.Alias.Value
+	}
+	if !isIdentifier(name) {
+		return newError("cannot bind module %q to a name, use import %q as NAME", is.Path, is.Path)
+	}
+	module := e.importModule(is.Token, is.Path)
+	if isError(module) {
+		return module
+	}
+	if err := e.Allocate(bindingSize); err != nil {
+		return err
+	}
+	env.Set(name, module)
+	return nil
+}
+
+// importModule loads the module file at path, or the standard module of
+// that name if there is no such file, once per Evaluator. The module is
+// evaluated in a fresh environment and only its exported bindings are kept.
+func (e *Evaluator) importModule(site token.Token, path string) object.Object {
+	file, ok := e.resolveModule(path)
+	if !ok {
+		if _, ok := stdModules[path]; ok {
+			return e.stdModule(path)
+		}
+		return newError("module not found: %s", path)
+	}
+	if module, ok := e.modules[file]; ok {
+		return module
+	}
+	for i, loading := range e.loading {
+		if loading == file {
+			var chain []string
+			for _, f := range append(e.loading[i:], file) {
+				chain = append(chain, filepath.Base(f))
+			}
+			return newError("import cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return newError("cannot read module %s: %s", path, err)
+	}
+	p := parser.MakeNewParser(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError("cannot parse module %s: %s", path, strings.Join(p.Errors(), "; "))
+	}
+
+	e.loading = append(e.loading, file)
+	defer func() {
+		e.loading = e.loading[:len(e.loading)-1]
+	}()
+	defer e.push(frame{name: "<module " + moduleName(path) + ">", call: site})()
+	macros := object.NewEnvironment(nil)
+	DefineMacros(program, macros)
+	expanded, failed := e.ExpandMacros(program, macros)
+	if failed != nil {
+		return failed
+	}
+	env := object.NewEnvironment(nil)
+	if result := e.eval(expanded, env); isError(result) {
+		return result
+	}
+
+	module := &object.Module{Name: moduleName(path), Path: file, Exports: map[string]object.Object{}}
+	for _, stmt := range program.Statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			names := []string{}
+			if export.Statement.Name != nil {
+				names = append(names, export.Statement.Name.Value)
+			} else {
+				names = patternNames(export.Statement.Pattern)
+			}
+			for _, name := range names {
+				module.Exports[name], _ = env.Get(name)
+			}
+		}
+	}
+	if e.modules == nil {
+		e.modules = map[string]*object.Module{}
+	}
+	e.modules[file] = module
+	return module
+}
+
+// resolveModule looks path up relative to the importing module, then in
+// each ModulePath directory, or the working directory if ModulePath is
+// empty.
+func (e *Evaluator) resolveModule(path string) (string, bool) {
+	if filepath.Ext(path) == "" {
+		path += ModuleExt
+	}
+	var dirs []string
+	if filepath.IsAbs(path) {
+		dirs = []string{""}
+	} else {
+		if n := len(e.loading); n > 0 {
+			dirs = append(dirs, filepath.Dir(e.loading[n-1]))
+		}
+		dirs = append(dirs, e.ModulePath...)
+		if len(e.ModulePath) == 0 {
+			dirs = append(dirs, ".")
+		}
+	}
+	for _, dir := range dirs {
+		file := filepath.Join(dir, path)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			abs, err := filepath.Abs(file)
+			return abs, err == nil
+		}
+	}
+	return "", false
+}
+
+func moduleName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// isIdentifier reports whether name would lex as a single identifier.
+func isIdentifier(name string) bool {
+	if name == "" || token.LookupIdent(name) != token.IDENT {
+		return false
+	}
+	for _, ch := range name {
+		if !('a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func evalPropertyExpression(left object.Object, name string) object.Object {
+	switch left := left.(type) {
+	case *object.Module:
+		value, ok := left.Exports[name]
+		if !ok {
+			return newError("module %s has no export %s", left.Name, name)
+		}
+		return value
+	case *object.ErrorValue:
+		return errorProperty(left.Err, name)
+	case *object.Hash:
+		value, ok := left.Get(&object.String{Value: name})
+		if !ok {
+			return NULL
+		}
+		return value
+	default:
+		return newError("property access not supported: %s", left.Type())
+	}
+}
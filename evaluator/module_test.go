@@ -0,0 +1,116 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeModules(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestImport(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"math.mk": `
+			let square = fn(x) { x * x };
+			export let sumOfSquares = fn(a, b) { square(a) + square(b) };
+			export let answer = 42;`,
+		"lib/greet.mk": `
+			import "helper";
+			export let hello = fn(name) { helper.prefix + name };`,
+		"lib/helper.mk": `export let prefix = "hello, ";`,
+	})
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`import "math"; math.answer`, 42},
+		{`import "math.mk"; math.sumOfSquares(3, 4)`, 25},
+		{`import "math" as m; m.answer + 1`, 43},
+		{`import "lib/greet"; greet.hello("monkey")`, "hello, monkey"},
+		{`import "math"; math.square(2)`, builtinError("module math has no export square")},
+		{`import "missing"`, builtinError("module not found: missing")},
+		{`import "math"; let x = 1; x.y`, builtinError("property access not supported: INTEGER")},
+		{`let h = {"a": 1}; [h.a, h.b]`, inspected("[1, null]")},
+	}
+
+	for _, tt := range tests {
+		e := New()
+		e.ModulePath = []string{dir}
+		testBuiltinResult(t, tt.input, testEvalWith(e, context.Background(), tt.input), tt.expected)
+	}
+}
+
+func TestImportLoadsModuleOnce(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"counter.mk": `puts("loading"); export let n = 1;`,
+		"a.mk":       `import "counter"; export let n = counter.n;`,
+	})
+
+	e := New()
+	var out bytes.Buffer
+	e.Out = &out
+	e.ModulePath = []string{dir}
+	testEvalWith(e, context.Background(), `import "counter"; import "a"; import "counter" as c;`)
+	testEvalWith(e, context.Background(), `import "counter"`)
+	if out.String() != "loading\n" {
+		t.Errorf("module evaluated more than once. output=%q", out.String())
+	}
+}
+
+func TestImportCycle(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"a.mk": `import "b"; export let x = 1;`,
+		"b.mk": `import "c";`,
+		"c.mk": `import "a";`,
+	})
+
+	e := New()
+	e.ModulePath = []string{dir}
+	evaluated := testEvalWith(e, context.Background(), `import "a"`)
+	testBuiltinResult(t, `import "a"`, evaluated, builtinError("import cycle: a.mk -> b.mk -> c.mk -> a.mk"))
+
+	// A failed import leaves nothing half-loaded behind.
+	if len(e.loading) != 0 {
+		t.Errorf("loading not unwound. got=%v", e.loading)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"broken.mk":  `let = 1;`,
+		"failing.mk": `export let x = 1 + true;`,
+		"my-mod.mk":  `export let x = 1;`,
+	})
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`import "broken"`, builtinError("cannot parse module broken: expected next token to be IDENT, got = instead")},
+		{`import "failing"`, builtinError("type mismatch: INTEGER + BOOLEAN")},
+		{`import "my-mod"`, builtinError(`cannot bind module "my-mod" to a name, use import "my-mod" as NAME`)},
+		{`import "my-mod" as m; m.x`, 1},
+	}
+
+	for _, tt := range tests {
+		e := New()
+		e.ModulePath = []string{dir}
+		testBuiltinResult(t, tt.input, testEvalWith(e, context.Background(), tt.input), tt.expected)
+	}
+}
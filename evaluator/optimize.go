@@ -0,0 +1,240 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+	"strconv"
+	"strings"
+)
+
+// Optimize rewrites program in place so that it does less work when
+// evaluated, without changing what it does. It folds prefix and infix
+// expressions on literals into the literal they evaluate to, drops the
+// branches of if expressions that a literal condition rules out, and
+// drops statements after a return or throw. Expressions that would fail,
+// such as 1 / 0 or "a" - "b", are left to fail when evaluated, and quoted
+// code is left alone. Macros should be expanded first.
+func Optimize(program *ast.Program) *ast.Program {
+	quoted := map[ast.Node]bool{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		// Whether quote is bound is only known when evaluating, so the
+		// arguments of any call of quote are left alone.
+		call, ok := node.(*ast.CallExpression)
+		if !ok || !IsQuote(call, func(string) bool { return false }) {
+			return true
+		}
+		for _, arg := range call.Arguments {
+			ast.Inspect(arg, func(n ast.Node) bool {
+				if n != nil {
+					quoted[n] = true
+				}
+				return true
+			})
+		}
+		return false
+	})
+
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		if quoted[node] {
+			return node
+		}
+		switch node := node.(type) {
+		case *ast.Program:
+			node.Statements = optimizeStatements(node.Statements)
+		case *ast.BlockStatement:
+			node.Statements = optimizeStatements(node.Statements)
+		case *ast.PrefixExpression:
+			return foldPrefix(node)
+		case *ast.InfixExpression:
+			return foldInfix(node)
+		case *ast.IfExpression:
+			return pruneIf(node)
+		}
+		return node
+	})
+	return program
+}
+
+func foldPrefix(node *ast.PrefixExpression) ast.Expression {
+	right, ok := constantValue(node.Right)
+	if !ok {
+		return node
+	}
+	if folded, ok := literalNode(evalPrefixExpression(node.Operator, right), node.Token); ok {
+		return folded
+	}
+	return node
+}
+
+func foldInfix(node *ast.InfixExpression) ast.Expression {
+	left, ok := constantValue(node.Left)
+	if !ok {
+		return node
+	}
+	right, ok := constantValue(node.Right)
+	if !ok {
+		return node
+	}
+	if divisor, ok := right.(*object.Integer); ok && divisor.Value == 0 && node.Operator == "/" {
+		return node
+	}
+	if folded, ok := literalNode(evalInfixExpression(node.Operator, left, right), literalToken(node.Left)); ok {
+		return folded
+	}
+	return node
+}
+
+// pruneIf drops the branches of node that its condition rules out. When
+// only the else branch can run, node becomes that branch, or an if with
+// a true condition for a plain else block, since a block is not an
+// expression. When no branch can run, the consequence is emptied.
+func pruneIf(node *ast.IfExpression) ast.Expression {
+	condition, ok := constantValue(node.Condition)
+	if !ok {
+		return node
+	}
+	if isTruthy(condition) {
+		node.Alternative = nil
+		return node
+	}
+	switch alternative := node.Alternative.(type) {
+	case *ast.IfExpression:
+		return alternative
+	case *ast.BlockStatement:
+		tok := literalToken(node.Condition)
+		tok.Type, tok.Literal = token.TRUE, "true"
+		node.Condition = &ast.BooleanExpression{Token: tok, Value: true}
+		node.Consequence, node.Alternative = alternative, nil
+	default:
+		node.Consequence = &ast.BlockStatement{Token: node.Consequence.Token, Rbrace: node.Consequence.Rbrace}
+	}
+	return node
+}
+
+// optimizeStatements drops the statements after a return or throw and
+// takes the branch an if statement with a literal condition runs out of
+// its block, when the block binds no names and so has no environment of
+// its own.
+func optimizeStatements(statements []ast.Statement) []ast.Statement {
+	optimized := make([]ast.Statement, 0, len(statements))
+	for i, stmt := range statements {
+		last := i == len(statements)-1
+		if block, ok := takenBranch(stmt); ok {
+			// An if that runs nothing evaluates to null, which only
+			// matters as the value of the last statement.
+			if block == nil && !last {
+				continue
+			}
+			if block != nil && len(block.Statements) > 0 && !declaresBindings(block) {
+				optimized = append(optimized, block.Statements...)
+				if exits(optimized[len(optimized)-1]) {
+					break
+				}
+				continue
+			}
+		}
+		optimized = append(optimized, stmt)
+		if exits(stmt) {
+			break
+		}
+	}
+	return optimized
+}
+
+// takenBranch returns the block stmt certainly runs if it is an if
+// statement whose condition is a literal, or nil if it runs none.
+func takenBranch(stmt ast.Statement) (*ast.BlockStatement, bool) {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return nil, false
+	}
+	ie, ok := es.Expression.(*ast.IfExpression)
+	if !ok || ie.Alternative != nil {
+		return nil, false
+	}
+	condition, ok := constantValue(ie.Condition)
+	if !ok {
+		return nil, false
+	}
+	if isTruthy(condition) {
+		return ie.Consequence, true
+	}
+	return nil, true
+}
+
+func exits(stmt ast.Statement) bool {
+	switch stmt.(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement:
+		return true
+	}
+	return false
+}
+
+// constantValue returns the value of a literal that evaluates to the same
+// value every time.
+func constantValue(expression ast.Expression) (object.Object, bool) {
+	switch e := expression.(type) {
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: e.Value}, true
+	case *ast.FloatLiteral:
+		return &object.Float{Value: e.Value}, true
+	case *ast.StringLiteral:
+		return &object.String{Value: e.Value}, true
+	case *ast.BooleanExpression:
+		return nativeBooleanObject(e.Value), true
+	}
+	return nil, false
+}
+
+// literalToken returns the token of a literal made by constantValue.
+func literalToken(expression ast.Expression) token.Token {
+	switch e := expression.(type) {
+	case *ast.IntegerLiteral:
+		return e.Token
+	case *ast.FloatLiteral:
+		return e.Token
+	case *ast.StringLiteral:
+		return e.Token
+	case *ast.BooleanExpression:
+		return e.Token
+	}
+	return token.Token{}
+}
+
+// literalNode returns the literal obj would be read from, at the position
+// of tok. There is none for errors, nor for values a literal could not be
+// written for in source, such as the smallest integer, infinite floats and
+// strings that would read as interpolated.
+func literalNode(obj object.Object, tok token.Token) (ast.Expression, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		// -9223372036854775808 reads as the negation of an integer that
+		// is too large.
+		if obj.Value == math.MinInt64 {
+			return nil, false
+		}
+		tok.Type, tok.Literal = token.INT, strconv.FormatInt(obj.Value, 10)
+		return &ast.IntegerLiteral{Token: tok, Value: obj.Value}, true
+	case *object.Float:
+		if math.IsInf(obj.Value, 0) || math.IsNaN(obj.Value) {
+			return nil, false
+		}
+		tok.Type, tok.Literal = token.FLOAT, strconv.FormatFloat(obj.Value, 'f', -1, 64)
+		return &ast.FloatLiteral{Token: tok, Value: obj.Value}, true
+	case *object.String:
+		if strings.Contains(obj.Value, "${") {
+			return nil, false
+		}
+		tok.Type, tok.Literal = token.STRING, obj.Value
+		return &ast.StringLiteral{Token: tok, Value: obj.Value}, true
+	case *object.Boolean:
+		tok.Type, tok.Literal = token.FALSE, "false"
+		if obj.Value {
+			tok.Type, tok.Literal = token.TRUE, "true"
+		}
+		return &ast.BooleanExpression{Token: tok, Value: obj.Value}, true
+	}
+	return nil, false
+}
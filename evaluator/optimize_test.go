@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"monkey/format"
+	"monkey/object"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let day = 24 * 60 * 60`, "let day = 86400;\n"},
+		{`"a" + "b" + "c"; 1.5 * 2; 1 + 0.5; !true; !!1; -(2 + 3)`, "\"abc\";\n3.0;\n1.5;\nfalse;\ntrue;\n-5;\n"},
+		{`1 < 2; 2 == 2.0; true != false; 1 == true`, "true;\ntrue;\ntrue;\nfalse;\n"},
+		{`x + 1 + 2; 1 + 2 + x`, "x + 1 + 2;\n3 + x;\n"},
+		{`(-3)[0]; -(1 - 4)(1)`, "(-3)[0];\n-(-3)(1);\n"},
+		// Expressions that fail, or whose value has no literal, are left as
+		// they are.
+		{`1 / 0; "a" - "b"; -true; 1.0 / 0`, "1 / 0;\n\"a\" - \"b\";\n-true;\n1.0 / 0;\n"},
+		{`"$" + "{x}"`, "\"$\" + \"{x}\";\n"},
+		{`let d = 9223372036854775807 + 1; -9223372036854775807 - 1`,
+			"let d = 9223372036854775807 + 1;\n-9223372036854775807 - 1;\n"},
+		{`quote(1 + 2); quote(unquote(1 + 2))`, "quote(1 + 2);\nquote(unquote(1 + 2));\n"},
+		{`if (1 < 2) { puts(1) } else { puts(2) }; x`, "puts(1);\nx;\n"},
+		{`if (false) { puts(1) }; x`, "x;\n"},
+		{`if (false) { puts(1) }`, "if (false) {}\n"},
+		{`let a = if (false) { 1 } else if (x) { 2 } else { 3 }`,
+			"let a = if (x) {\n\t2;\n} else {\n\t3;\n};\n"},
+		{`let a = if ("s") { 1 } else { 2 }`, "let a = if (\"s\") {\n\t1;\n};\n"},
+		{`let a = if (0 > 1) { 1 } else { 2 }`, "let a = if (true) {\n\t2;\n};\n"},
+		{`if (true) { let y = 1; y }`, "if (true) {\n\tlet y = 1;\n\ty;\n}\n"},
+		{`let f = fn() { puts(1); return 2; puts(3); 4 }`, "let f = fn() {\n\tputs(1);\n\treturn 2;\n};\n"},
+		{`let f = fn() { if (true) { throw "e" }; puts(1) }`, "let f = fn() {\n\tthrow \"e\";\n};\n"},
+		{`return 1; puts(2)`, "return 1;\n"},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(testParseProgram(tt.input))
+		if out := format.Program(program); out != tt.expected {
+			t.Errorf("%q: wrong output.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, out)
+		}
+	}
+}
+
+func TestOptimizeKeepsSemantics(t *testing.T) {
+	inputs := []string{
+		`let f = fn(x) { if (2 * 2 == 4) { x * (60 * 60) } else { 0 } }; f(2)`,
+		`let f = fn() { 5; if (false) { 1 } }; f()`,
+		`let f = fn() { 5; if (true) {} }; f()`,
+		`let f = fn() { if (true) { return 1 }; 2 }; f()`,
+		`let x = 1; if (true) { let x = 2 }; x`,
+		`"a" + "b" - "c"`,
+		`1 + (2 - true)`,
+		`let f = fn() { throw "e" + "!"; 1 }; try { f() } catch (e) { e.message }`,
+		`match (1 + 1) { 2 => "two" + "!", _ => "other" }`,
+		`[1 + 1, {"k" + "ey": !false}["key"], -2.5 * 2]`,
+		`let m = quote(1 + unquote(2 * 3)); m`,
+	}
+
+	for _, input := range inputs {
+		expected := Eval(testParseProgram(input), object.NewEnvironment(nil))
+		got := Eval(Optimize(testParseProgram(input)), object.NewEnvironment(nil))
+		if inspect(got) != inspect(expected) {
+			t.Errorf("%q: result changed. expected=%s, got=%s", input, inspect(expected), inspect(got))
+		}
+		expectedErr, ok := expected.(*object.Error)
+		if !ok {
+			continue
+		}
+		if gotErr, ok := got.(*object.Error); !ok || gotErr.Line != expectedErr.Line || gotErr.Column != expectedErr.Column {
+			t.Errorf("%q: error moved. expected=%+v, got=%+v", input, expectedErr, got)
+		}
+	}
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return obj.Inspect()
+}
@@ -0,0 +1,251 @@
+package evaluator
+
+import (
+	"monkey/ast"
+)
+
+// Resolve works out where the binding each identifier in program refers
+// to lives, so that local names are looked up by index instead of by
+// searching environments by name. Names bound at the top level of a
+// program stay in its map-based environment, where the REPL and hosts
+// keep adding bindings, and are still looked up by name.
+//
+// The resolver makes a Scope wherever the evaluator makes an environment:
+// for each function call, block that binds names, match arm and catch
+// clause with a parameter. A Scope lists every name bound directly in it,
+// including those bound after a use, since functions may run after they
+// are bound. Evaluating a program resolves it first, and resolving it
+// again is harmless.
+func Resolve(program *ast.Program) {
+	resolveStatements(program.Statements, &scope{})
+}
+
+type scope struct {
+	outer *scope
+	// layout is nil for the program's scope.
+	layout *ast.Scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, layout: &ast.Scope{}}
+}
+
+// slot returns the slot of name in s, adding one if needed.
+func (s *scope) slot(name string) int {
+	for i, n := range s.layout.Names {
+		if n == name {
+			return i
+		}
+	}
+	s.layout.Names = append(s.layout.Names, name)
+	return len(s.layout.Names) - 1
+}
+
+// binds reports whether name is bound in s or a scope around it. Names
+// bound at the top level are not tracked, so a call of a top-level quote
+// function is treated as quote; its unresolved arguments are then looked
+// up by name, which evaluates the same.
+func (s *scope) binds(name string) bool {
+	for ; s.layout != nil; s = s.outer {
+		for _, n := range s.layout.Names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// declare binds ident in s.
+func (s *scope) declare(ident *ast.Identifier) {
+	if s.layout == nil {
+		ident.Scope, ident.Depth, ident.Slot = nil, 0, 0
+		return
+	}
+	ident.Scope, ident.Depth, ident.Slot = s.layout, 0, s.slot(ident.Value)
+}
+
+// resolve locates the binding of ident from s.
+func (s *scope) resolve(ident *ast.Identifier) {
+	depth := 0
+	for ; s.layout != nil; s = s.outer {
+		for i, name := range s.layout.Names {
+			if name == ident.Value {
+				ident.Scope, ident.Depth, ident.Slot = s.layout, depth, i
+				return
+			}
+		}
+		depth++
+	}
+	ident.Scope, ident.Depth, ident.Slot = nil, depth, 0
+}
+
+func resolveStatements(statements []ast.Statement, s *scope) {
+	for _, stmt := range statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			stmt = export.Statement
+		}
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			if stmt.Name != nil {
+				s.declare(stmt.Name)
+			} else {
+				declarePattern(stmt.Pattern, s)
+			}
+		case *ast.ImportStatement:
+			if stmt.Alias != nil {
+				s.declare(stmt.Alias)
+			} else if s.layout != nil {
+				s.slot(moduleName(stmt.Path))
+			}
+		}
+	}
+	for _, stmt := range statements {
+		resolveNode(stmt, s)
+	}
+}
+
+// declarePattern binds the names in pattern in s and resolves the
+// expressions it evaluates.
+func declarePattern(pattern ast.Pattern, s *scope) {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		s.declare(pattern.Name)
+	case *ast.LiteralPattern:
+		resolveNode(pattern.Value, s)
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			declarePattern(alternative, s)
+		}
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			declarePattern(element, s)
+		}
+		if pattern.Rest != nil {
+			s.declare(pattern.Rest)
+		}
+	case *ast.HashPattern:
+		for i := range pattern.Keys {
+			resolveNode(pattern.Keys[i], s)
+			declarePattern(pattern.Values[i], s)
+		}
+	}
+}
+
+func resolveBlock(block *ast.BlockStatement, s *scope) {
+	if block == nil {
+		return
+	}
+	block.Scope = nil
+	if declaresBindings(block) {
+		s = newScope(s)
+		block.Scope = s.layout
+	}
+	resolveStatements(block.Statements, s)
+}
+
+func resolveNode(node ast.Node, s *scope) {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		s.resolve(node)
+	case *ast.LetStatement:
+		resolveNode(node.Value, s)
+	case *ast.ExportStatement:
+		resolveNode(node.Statement, s)
+	case *ast.ReturnStatement:
+		if node.ReturnValue != nil {
+			resolveNode(node.ReturnValue, s)
+		}
+	case *ast.ThrowStatement:
+		resolveNode(node.Value, s)
+	case *ast.ExpressionStatement:
+		if node.Expression != nil {
+			resolveNode(node.Expression, s)
+		}
+	case *ast.BlockStatement:
+		resolveBlock(node, s)
+	case *ast.PrefixExpression:
+		resolveNode(node.Right, s)
+	case *ast.InfixExpression:
+		resolveNode(node.Left, s)
+		resolveNode(node.Right, s)
+	case *ast.IndexExpression:
+		resolveNode(node.Left, s)
+		resolveNode(node.Index, s)
+	case *ast.PropertyExpression:
+		resolveNode(node.Left, s)
+	case *ast.InterpolatedString:
+		for _, part := range node.Parts {
+			resolveNode(part, s)
+		}
+	case *ast.ArrayLiteral:
+		for _, element := range node.Elements {
+			resolveNode(element, s)
+		}
+	case *ast.HashLiteral:
+		for i := range node.Keys {
+			resolveNode(node.Keys[i], s)
+			resolveNode(node.Values[i], s)
+		}
+	case *ast.CallExpression:
+		if IsQuote(node, s.binds) {
+			// Only what is unquoted is evaluated where the quote is.
+			for _, arg := range node.Arguments {
+				ast.Inspect(arg, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpression)
+					if !ok || call.Function.TokenLiteral() != "unquote" {
+						return true
+					}
+					for _, arg := range call.Arguments {
+						resolveNode(arg, s)
+					}
+					return false
+				})
+			}
+			return
+		}
+		resolveNode(node.Function, s)
+		for _, arg := range node.Arguments {
+			resolveNode(arg, s)
+		}
+	case *ast.FunctionLiteral:
+		// The body is evaluated in the call's environment, along with the
+		// parameters, rather than in one of its own.
+		fs := newScope(s)
+		node.Scope = fs.layout
+		for _, param := range node.Parameters {
+			declarePattern(param, fs)
+		}
+		node.Body.Scope = nil
+		resolveStatements(node.Body.Statements, fs)
+	case *ast.IfExpression:
+		resolveNode(node.Condition, s)
+		resolveBlock(node.Consequence, s)
+		if node.Alternative != nil {
+			resolveNode(node.Alternative, s)
+		}
+	case *ast.TryExpression:
+		resolveBlock(node.Block, s)
+		node.CatchScope = nil
+		if node.Param != nil {
+			cs := newScope(s)
+			node.CatchScope = cs.layout
+			cs.declare(node.Param)
+			resolveBlock(node.Catch, cs)
+		} else {
+			resolveBlock(node.Catch, s)
+		}
+		resolveBlock(node.Finally, s)
+	case *ast.MatchExpression:
+		resolveNode(node.Subject, s)
+		for _, arm := range node.Arms {
+			as := newScope(s)
+			arm.Scope = as.layout
+			declarePattern(arm.Pattern, as)
+			if arm.Guard != nil {
+				resolveNode(arm.Guard, as)
+			}
+			resolveNode(arm.Body, as)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestResolveLocatesBindings(t *testing.T) {
+	program := testParseProgram(`
+let g = 1;
+let f = fn(a, [b, c]) {
+	let d = a;
+	fn(e) { [a, c, d, e, g, len] }
+};`)
+	Resolve(program)
+
+	type location struct {
+		local       bool
+		depth, slot int
+	}
+	expected := map[string]location{
+		"a":   {true, 1, 0},
+		"c":   {true, 1, 2},
+		"d":   {true, 1, 3},
+		"e":   {true, 0, 0},
+		"g":   {false, 2, 0},
+		"len": {false, 2, 0},
+	}
+	var inner *ast.FunctionLiteral
+	ast.Inspect(program, func(node ast.Node) bool {
+		if fn, ok := node.(*ast.FunctionLiteral); ok && len(fn.Parameters) == 1 {
+			inner = fn
+		}
+		return true
+	})
+	if inner == nil {
+		t.Fatalf("inner function not found")
+	}
+	array := inner.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ArrayLiteral)
+	for _, element := range array.Elements {
+		ident := element.(*ast.Identifier)
+		want := expected[ident.Value]
+		got := location{ident.Scope != nil, ident.Depth, ident.Slot}
+		if got != want {
+			t.Errorf("%s: wrong location. expected=%+v, got=%+v", ident.Value, want, got)
+		}
+	}
+	if inner.Scope == nil || len(inner.Scope.Names) != 1 {
+		t.Errorf("wrong inner scope. got=%+v", inner.Scope)
+	}
+}
+
+func TestResolvedEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// A use before a binding in the same scope means the outer name.
+		{`let x = 1; let f = fn() { let y = x; let x = 2; y * 10 + x }; f()`, 12},
+		// Functions see bindings made after them once they are made.
+		{`let x = 1; let f = fn() { let g = fn() { x }; let a = g(); let x = 2; [a, g()] }; f()`, inspected("[1, 2]")},
+		{`let f = fn() { let x = 1; [if (true) { let x = 2; x }, x] }; f()`, inspected("[2, 1]")},
+		{`let f = fn(v) { match (v) { [a, ...r] if a > 0 => a + len(r), {a} => a, _ => 0 } }; [f([1, 2]), f({"a": 5}), f(-1)]`, inspected("[2, 5, 0]")},
+		{`let f = fn() { try { throw "boom" } catch (e) { let m = e.message; m } }; f()`, "boom"},
+		{`let f = fn() { import "math" as m; m.abs(-3) }; f()`, 3},
+		{`let counter = fn() { let n = 0; fn(k) { n + k } }; let c = counter(); c(5)`, 5},
+		{`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5)`, 120},
+		{`let f = fn(a, a) { a }; f(1, 2)`, 2},
+		{`let f = fn() { y }; f()`, builtinError("identifier not found: y")},
+		{`let f = fn() { let y = 1; quote(y + unquote(y)) }; f()`, inspected("QUOTE((y + 1))")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestResolveSplicedMacroArguments(t *testing.T) {
+	// The argument is spliced in at two sites with different scopes
+	// around it, and must be resolved for each on its own.
+	program := testParseProgram(`let x = 100;
+		let m = macro(e) { quote(fn(x) { unquote(e) }(1) + fn(y) { unquote(e) }(2)) };
+		m(x)`)
+	macros := object.NewEnvironment(nil)
+	DefineMacros(program, macros)
+	expanded, err := New().ExpandMacros(program, macros)
+	if err != nil {
+		t.Fatalf("ExpandMacros returned error: %s", err.Inspect())
+	}
+	testIntegerObject(t, Eval(expanded, object.NewEnvironment(nil)), 101)
+}
+
+func TestResolveWithIncrementalGlobals(t *testing.T) {
+	// Like the REPL, evaluate programs one at a time in the same
+	// environment: globals are still bound and looked up by name.
+	env := object.NewEnvironment(nil)
+	e := New()
+	inputs := []string{
+		`let f = fn(x) { x + later };`,
+		`let later = 10;`,
+		`f(1)`,
+	}
+	var result object.Object
+	for _, input := range inputs {
+		program := parser.MakeNewParser(lexer.New(input)).ParseProgram()
+		result = e.Eval(program, env)
+	}
+	testIntegerObject(t, result, 11)
+	if _, ok := env.Get("later"); !ok {
+		t.Errorf("later not bound by name")
+	}
+}
+
+func TestResolveTwice(t *testing.T) {
+	program := testParseProgram(`let f = fn(a) { let b = a * 2; fn() { a + b } }; f(3)()`)
+	Resolve(program)
+	Resolve(program)
+	testIntegerObject(t, Eval(program, object.NewEnvironment(nil)), 9)
+}
+
+func TestEnvironmentFallsBackToNames(t *testing.T) {
+	// Unresolved identifiers, as in macro bodies, are looked up by name
+	// even in environments with slots.
+	scope := &ast.Scope{Names: []string{"a"}}
+	env := object.NewScopedEnvironment(object.NewEnvironment(nil), scope)
+	env.Set("a", &object.Integer{Value: 1})
+	env.Set("b", &object.Integer{Value: 2})
+
+	for _, name := range []string{"a", "b"} {
+		if _, ok := env.Lookup(&ast.Identifier{Value: name}); !ok {
+			t.Errorf("%s not found", name)
+		}
+	}
+	resolved := &ast.Identifier{Value: "a", Scope: scope, Slot: 0}
+	if obj, ok := env.Lookup(resolved); !ok {
+		t.Errorf("resolved a not found")
+	} else {
+		testIntegerObject(t, obj, 1)
+	}
+}
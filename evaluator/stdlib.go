@@ -0,0 +1,69 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/object"
+)
+
+// stdModules builds the standard modules implemented in Go. They can be
+// used without an import, as in math.sqrt(2), but a module file of the
+// same name takes precedence when imported.
+var stdModules = map[string]func(e *Evaluator) *object.Module{
+	"math":    mathModule,
+	"time":    timeModule,
+	"random":  randomModule,
+	"json":    jsonModule,
+}
+
+// IsStdModule reports whether name is a standard module, which scripts
+// can use without importing it.
+func IsStdModule(name string) bool {
+	_, ok := stdModules[name]
+	return ok
+}
+
+// stdModule builds the standard module called name once per Evaluator,
+// since the time and random modules hold on to its Clock and Rand.
+func (e *Evaluator) stdModule(name string) *object.Module {
+	if module, ok := e.modules[name]; ok {
+		return module
+	}
+	module := stdModules[name](e)
+	if e.modules == nil {
+		e.modules = map[string]*object.Module{}
+	}
+	e.modules[name] = module
+	return module
+}
+
+func newModule(name string, exports map[string]object.Object) *object.Module {
+	for export, obj := range exports {
+		if builtin, ok := obj.(*object.Builtin); ok && builtin.Name == "" {
+			builtin.Name = name + "." + export
+		}
+	}
+	return &object.Module{Name: name, Path: name, Exports: exports}
+}
+
+func builtinFunc(fn object.BuiltinFunction) *object.Builtin {
+	return &object.Builtin{Fn: fn}
+}
+
+// numberArg unwraps an INTEGER or FLOAT argument of the function called
+// name.
+func numberArg(name string, arg object.Object) (float64, *object.Error) {
+	if !isNumber(arg) {
+		return 0, newError("argument to `%s` must be INTEGER or FLOAT, got %s",
+			name, arg.Type())
+	}
+	return toFloat(arg), nil
+}
+
+// integerResult converts f back to an INTEGER, failing when it does not
+// fit.
+func integerResult(name string, f float64) object.Object {
+	if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return newError("%s: %g out of INTEGER range", name, f)
+	}
+	return &object.Integer{Value: int64(f)}
+}
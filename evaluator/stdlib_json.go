@@ -0,0 +1,169 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"monkey/object"
+	"strings"
+)
+
+func jsonModule(e *Evaluator) *object.Module {
+	return newModule("json", map[string]object.Object{
+		"parse": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			src, _, err := stringArgs("json.parse", args[0])
+			if err != nil {
+				return err
+			}
+			dec := json.NewDecoder(strings.NewReader(src))
+			dec.UseNumber()
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return newError("json.parse: %s", err)
+			}
+			if _, err := dec.Token(); err != io.EOF {
+				return newError("json.parse: unexpected data after top-level value")
+			}
+			return fromJSON(rt, value)
+		}),
+		"stringify": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2",
+					len(args))
+			}
+			indent := ""
+			if len(args) == 2 {
+				n, err := integerArgs("json.stringify", args[1])
+				if err != nil {
+					return err
+				}
+				// Like JavaScript, indentation is capped at ten spaces.
+				if n[0] > 10 {
+					n[0] = 10
+				}
+				if n[0] > 0 {
+					indent = strings.Repeat(" ", int(n[0]))
+				}
+			}
+			value, err := toJSON(args[0], "")
+			if err != nil {
+				return err
+			}
+			var out bytes.Buffer
+			enc := json.NewEncoder(&out)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", indent)
+			if err := enc.Encode(value); err != nil {
+				return newError("json.stringify: %s", err)
+			}
+			return track(rt, &object.String{Value: strings.TrimSuffix(out.String(), "\n")})
+		}),
+	})
+}
+
+// fromJSON converts a value decoded with UseNumber into Monkey objects.
+// Numbers become INTEGER when they are whole and fit, FLOAT otherwise.
+func fromJSON(rt object.Runtime, value interface{}) object.Object {
+	switch value := value.(type) {
+	case nil:
+		return NULL
+	case bool:
+		return nativeBooleanObject(value)
+	case string:
+		return track(rt, &object.String{Value: value})
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return &object.Integer{Value: n}
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return newError("json.parse: %s", err)
+		}
+		return &object.Float{Value: f}
+	case []interface{}:
+		elements := make([]object.Object, len(value))
+		for i, v := range value {
+			elements[i] = fromJSON(rt, v)
+			if isError(elements[i]) {
+				return elements[i]
+			}
+		}
+		return track(rt, &object.Array{Elements: elements})
+	case map[string]interface{}:
+		hash := object.NewHash()
+		for k, v := range value {
+			obj := fromJSON(rt, v)
+			if isError(obj) {
+				return obj
+			}
+			hash.Set(&object.String{Value: k}, obj)
+		}
+		return track(rt, hash)
+	default:
+		return newError("json.parse: unexpected %T", value)
+	}
+}
+
+// toJSON converts obj into values encoding/json can marshal. Hash keys
+// are written as strings and, like all map keys, in sorted order. path
+// locates obj in the value being stringified for error messages.
+func toJSON(obj object.Object, path string) (interface{}, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.NULL:
+		return nil, nil
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return nil, unserialisable(obj.Inspect(), path)
+		}
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Array:
+		values := make([]interface{}, len(obj.Elements))
+		for i, element := range obj.Elements {
+			value, err := toJSON(element, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case *object.Hash:
+		values := make(map[string]interface{}, len(obj.Pairs))
+		for _, pair := range obj.SortedPairs() {
+			key := pair.Key.Inspect()
+			if _, ok := values[key]; ok {
+				return nil, newError("json.stringify: duplicate key %q at %s", key, pathOrRoot(path))
+			}
+			value, err := toJSON(pair.Value, path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+		}
+		return values, nil
+	default:
+		return nil, unserialisable(string(obj.Type()), path)
+	}
+}
+
+func unserialisable(what, path string) *object.Error {
+	return newError("json.stringify: cannot serialise %s at %s", what, pathOrRoot(path))
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "top level"
+	}
+	return strings.TrimPrefix(path, ".")
+}
@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/object"
+)
+
+func mathModule(e *Evaluator) *object.Module {
+	return newModule("math", map[string]object.Object{
+		"pi": &object.Float{Value: math.Pi},
+		"e":  &object.Float{Value: math.E},
+
+		"abs": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				if arg.Value < 0 {
+					return &object.Integer{Value: -arg.Value}
+				}
+				return arg
+			case *object.Float:
+				return &object.Float{Value: math.Abs(arg.Value)}
+			default:
+				return newError("argument to `math.abs` must be INTEGER or FLOAT, got %s",
+					args[0].Type())
+			}
+		}),
+		"min": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			return extremum("math.min", args, func(a, b float64) bool { return a < b })
+		}),
+		"max": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			return extremum("math.max", args, func(a, b float64) bool { return a > b })
+		}),
+		"pow": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			base, exp := args[0], args[1]
+			if b, ok := base.(*object.Integer); ok {
+				if n, ok := exp.(*object.Integer); ok && n.Value >= 0 {
+					return &object.Integer{Value: intPow(b.Value, n.Value)}
+				}
+			}
+			x, err := numberArg("math.pow", base)
+			if err != nil {
+				return err
+			}
+			y, err := numberArg("math.pow", exp)
+			if err != nil {
+				return err
+			}
+			return &object.Float{Value: math.Pow(x, y)}
+		}),
+		"sqrt":  floatFunc("math.sqrt", math.Sqrt),
+		"sin":   floatFunc("math.sin", math.Sin),
+		"cos":   floatFunc("math.cos", math.Cos),
+		"tan":   floatFunc("math.tan", math.Tan),
+		"asin":  floatFunc("math.asin", math.Asin),
+		"acos":  floatFunc("math.acos", math.Acos),
+		"atan":  floatFunc("math.atan", math.Atan),
+		"floor": roundingFunc("math.floor", math.Floor),
+		"ceil":  roundingFunc("math.ceil", math.Ceil),
+		"round": roundingFunc("math.round", math.Round),
+	})
+}
+
+// floatFunc wraps a one-argument function of float64 that returns a FLOAT.
+func floatFunc(name string, fn func(float64) float64) *object.Builtin {
+	return builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1",
+				len(args))
+		}
+		x, err := numberArg(name, args[0])
+		if err != nil {
+			return err
+		}
+		return &object.Float{Value: fn(x)}
+	})
+}
+
+// roundingFunc wraps a rounding function; it returns an INTEGER so the
+// result can be used as an index.
+func roundingFunc(name string, fn func(float64) float64) *object.Builtin {
+	return builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1",
+				len(args))
+		}
+		if integer, ok := args[0].(*object.Integer); ok {
+			return integer
+		}
+		x, err := numberArg(name, args[0])
+		if err != nil {
+			return err
+		}
+		return integerResult(name, fn(x))
+	})
+}
+
+// extremum returns the argument for which better holds against all the
+// others, keeping its type.
+func extremum(name string, args []object.Object, better func(a, b float64) bool) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, want at least 1")
+	}
+	best := args[0]
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return newError("argument to `%s` must be INTEGER or FLOAT, got %s",
+				name, arg.Type())
+		}
+		if better(toFloat(arg), toFloat(best)) {
+			best = arg
+		}
+	}
+	return best
+}
+
+func intPow(base, exp int64) int64 {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exp >>= 1
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package evaluator
+
+import (
+	"math/rand"
+	"monkey/object"
+	"time"
+)
+
+func (e *Evaluator) rand() *rand.Rand {
+	if e.Rand == nil {
+		e.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return e.Rand
+}
+
+func randomModule(e *Evaluator) *object.Module {
+	return newModule("random", map[string]object.Object{
+		"seed": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			seed, err := integerArgs("random.seed", args[0])
+			if err != nil {
+				return err
+			}
+			e.Rand = rand.New(rand.NewSource(seed[0]))
+			return NULL
+		}),
+		"int": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			n, err := integerArgs("random.int", args[0])
+			if err != nil {
+				return err
+			}
+			if n[0] <= 0 {
+				return newError("argument to `random.int` must be positive, got %d", n[0])
+			}
+			return &object.Integer{Value: e.rand().Int63n(n[0])}
+		}),
+		"float": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0",
+					len(args))
+			}
+			return &object.Float{Value: e.rand().Float64()}
+		}),
+		"choice": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			arr, err := arrayArg("random.choice", args[0])
+			if err != nil {
+				return err
+			}
+			if len(arr.Elements) == 0 {
+				return NULL
+			}
+			return arr.Elements[e.rand().Intn(len(arr.Elements))]
+		}),
+		"shuffle": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			arr, err := arrayArg("random.shuffle", args[0])
+			if err != nil {
+				return err
+			}
+			shuffled := newArray(arr.Elements)
+			e.rand().Shuffle(len(shuffled.Elements), func(i, j int) {
+				shuffled.Elements[i], shuffled.Elements[j] = shuffled.Elements[j], shuffled.Elements[i]
+			})
+			return track(rt, shuffled)
+		}),
+	})
+}
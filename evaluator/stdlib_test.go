@@ -0,0 +1,155 @@
+package evaluator
+
+import (
+	"context"
+	"math/rand"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`math.abs(-3)`, 3},
+		{`math.abs(-2.5)`, inspected("2.5")},
+		{`math.min(3, 1.5, 2)`, inspected("1.5")},
+		{`math.max(3, 1.5, 2)`, 3},
+		{`math.max()`, builtinError("wrong number of arguments. got=0, want at least 1")},
+		{`math.pow(2, 10)`, 1024},
+		{`math.pow(2, -1)`, inspected("0.5")},
+		{`math.pow(4, 0.5)`, inspected("2.0")},
+		{`math.sqrt(16)`, inspected("4.0")},
+		{`math.sqrt("16")`, builtinError("argument to `math.sqrt` must be INTEGER or FLOAT, got STRING")},
+		{`math.floor(2.7)`, 2},
+		{`math.floor(-2.5)`, -3},
+		{`math.ceil(2.1)`, 3},
+		{`math.round(2.5)`, 3},
+		{`math.round(7)`, 7},
+		{`math.floor(1.0 / 0)`, builtinError("math.floor: +Inf out of INTEGER range")},
+		{`math.sin(0)`, inspected("0.0")},
+		{`math.cos(math.pi)`, inspected("-1.0")},
+		{`math.atan(1) * 4 == math.pi`, true},
+		{`import "math" as m; m.abs(-1)`, 1},
+		{`let math = {"abs": 5}; math.abs`, 5},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTimeModule(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC)}
+	e := New()
+	e.Clock = clock
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`time.now()`, 1709209800000},
+		{`time.format(time.now(), "2006-01-02 15:04")`, "2024-02-29 12:30"},
+		{`time.parse("2024-03-01", "2006-01-02") - time.now()`, 41400000},
+		{`time.parse("yesterday", "2006-01-02")`,
+			builtinError(`time.parse: parsing time "yesterday" as "2006-01-02": cannot parse "yesterday" as "2006"`)},
+		{`let start = time.now(); time.sleep(1500); time.now() - start`, 1500},
+		{`time.sleep("1s")`, builtinError("argument to `time.sleep` must be INTEGER, got STRING")},
+	}
+
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEvalWith(e, context.Background(), tt.input), tt.expected)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e.ctx = ctx
+	sleep := e.stdModule("time").Exports["sleep"].(*object.Builtin)
+	testAbortError(t, sleep.Fn(e, &object.Integer{Value: 10}), object.CANCELED_ERR, "evaluation canceled")
+}
+
+func TestRandomModule(t *testing.T) {
+	input := `random.seed(42); [random.int(100), random.int(100), random.shuffle([1, 2, 3, 4]), random.choice(["a", "b", "c"])]`
+	first := testEval(input)
+	second := testEval(input)
+	if isError(first) {
+		t.Fatalf("random module failed: %s", first.Inspect())
+	}
+	if first.Inspect() != second.Inspect() {
+		t.Errorf("seeded results differ: %s and %s", first.Inspect(), second.Inspect())
+	}
+
+	e := New()
+	e.Rand = rand.New(rand.NewSource(42))
+	third := testEvalWith(e, context.Background(), input[len("random.seed(42); "):])
+	if first.Inspect() != third.Inspect() {
+		t.Errorf("Evaluator.Rand not used: %s and %s", first.Inspect(), third.Inspect())
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`random.int(0)`, builtinError("argument to `random.int` must be positive, got 0")},
+		{`random.choice([])`, nil},
+		{`len(random.shuffle(range(10)))`, 10},
+	}
+	for _, tt := range tests {
+		testBuiltinResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+	f := testEval(`random.float()`).(*object.Float).Value
+	if f < 0 || f >= 1 {
+		t.Errorf("random.float() out of range: %g", f)
+	}
+}
+
+func TestJSONModule(t *testing.T) {
+	tests := []struct {
+		doc      string
+		input    string
+		expected interface{}
+	}{
+		{`{"b": [1, 2.5, "x"], "a": {"ok": true, "none": null}}`, `json.parse(doc)`,
+			inspected("{a: {none: null, ok: true}, b: [1, 2.5, x]}")},
+		{`42`, `json.parse(doc)`, 42},
+		{`1e3`, `json.parse(doc)`, inspected("1000.0")},
+		{`[1,`, `json.parse(doc)`, builtinError("json.parse: unexpected EOF")},
+		{`1 2`, `json.parse(doc)`, builtinError("json.parse: unexpected data after top-level value")},
+		{``, `json.parse(1)`, builtinError("argument to `json.parse` must be STRING, got INTEGER")},
+		{`null`, `json.stringify({"b": 1, "a": [true, json.parse(doc), 1.5, "<x>"]})`, `{"a":[true,null,1.5,"<x>"],"b":1}`},
+		{``, `json.stringify({"a": [1]}, 2)`, "{\n  \"a\": [\n    1\n  ]\n}"},
+		{``, `json.stringify({1: "one", true: "yes"})`, `{"1":"one","true":"yes"}`},
+		{``, `json.stringify({"a": [1, fn(x) { x }]})`, builtinError("json.stringify: cannot serialise FUNCTION at a[1]")},
+		{``, `json.stringify(len)`, builtinError("json.stringify: cannot serialise BUILTIN at top level")},
+		{``, `json.stringify([1.0 / 0])`, builtinError("json.stringify: cannot serialise +Inf at [0]")},
+		{``, `json.stringify({1: "a", "1": "b"})`, builtinError(`json.stringify: duplicate key "1" at top level`)},
+		{`{"port": 80}`, `let config = json.parse(doc); json.stringify({"port": config.port + 8000})`, `{"port":8080}`},
+	}
+
+	for _, tt := range tests {
+		program := parser.MakeNewParser(lexer.New(tt.input)).ParseProgram()
+		env := object.NewEnvironment(nil)
+		env.Set("doc", &object.String{Value: tt.doc})
+		testBuiltinResult(t, tt.input, Eval(program, env), tt.expected)
+	}
+}
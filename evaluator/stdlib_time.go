@@ -0,0 +1,101 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"time"
+)
+
+// Clock is the time source of the time module, so that hosts and tests
+// can control it.
+type Clock interface {
+	Now() time.Time
+	// Sleep pauses for d, or returns ctx.Err() once ctx is done.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Evaluator) clock() Clock {
+	if e.Clock == nil {
+		return systemClock{}
+	}
+	return e.Clock
+}
+
+// timeModule represents instants as INTEGER milliseconds since the Unix
+// epoch, formatted and parsed in UTC with Go's reference layouts.
+func timeModule(e *Evaluator) *object.Module {
+	return newModule("time", map[string]object.Object{
+		"now": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0",
+					len(args))
+			}
+			return &object.Integer{Value: e.clock().Now().UnixMilli()}
+		}),
+		"format": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			ms, err := integerArgs("time.format", args[0])
+			if err != nil {
+				return err
+			}
+			layout, _, err := stringArgs("time.format", args[1])
+			if err != nil {
+				return err
+			}
+			formatted := time.UnixMilli(ms[0]).UTC().Format(layout)
+			return track(rt, &object.String{Value: formatted})
+		}),
+		"parse": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			value, layout, err := stringArgs("time.parse", args[0], args[1])
+			if err != nil {
+				return err
+			}
+			parsed, parseErr := time.Parse(layout, value)
+			if parseErr != nil {
+				return newError("time.parse: %s", parseErr)
+			}
+			return &object.Integer{Value: parsed.UnixMilli()}
+		}),
+		"sleep": builtinFunc(func(rt object.Runtime, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			ms, err := integerArgs("time.sleep", args[0])
+			if err != nil {
+				return err
+			}
+			if ms[0] <= 0 {
+				return NULL
+			}
+			if err := e.clock().Sleep(rt.Context(), time.Duration(ms[0])*time.Millisecond); err != nil {
+				return contextError(err)
+			}
+			return NULL
+		}),
+	})
+}
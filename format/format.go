@@ -0,0 +1,584 @@
+// Package format prints Monkey programs in canonical style: one statement
+// per line, blocks indented with tabs, only the parentheses the grammar
+// needs, and the comments and single blank lines of the source kept where
+// they were.
+package format
+
+import (
+	"bytes"
+	"math"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParseError is returned when the source given to Source does not parse.
+type ParseError struct {
+	Messages []string
+}
+
+func (pe *ParseError) Error() string {
+	return "parse error: " + strings.Join(pe.Messages, "; ")
+}
+
+// Source formats the Monkey program in src. Formatting the result again
+// changes nothing, and parsing it gives the same program as parsing src.
+func Source(src []byte) ([]byte, error) {
+	l := lexer.New(string(src))
+	p := parser.MakeNewParser(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Messages: p.Errors()}
+	}
+	pr := newPrinter()
+	pr.lines = strings.Split(string(src), "\n")
+	pr.comments = l.Comments()
+	pr.program(program)
+	return pr.out.Bytes(), nil
+}
+
+// Program formats program. Without the source, there are no comments or
+// blank lines to keep.
+func Program(program *ast.Program) string {
+	pr := newPrinter()
+	pr.program(program)
+	return pr.out.String()
+}
+
+type printer struct {
+	out    bytes.Buffer
+	indent int
+	// lineStart is true until something is written on the current line,
+	// which is indented only then.
+	lineStart bool
+	// opened is true right after the "{" of an indented body, where
+	// blank lines from the source are dropped.
+	opened bool
+	// commented is true once a trailing comment ends the current line.
+	commented bool
+
+	lines    []string
+	comments []token.Token
+}
+
+func newPrinter() *printer {
+	return &printer{lineStart: true}
+}
+
+func (p *printer) write(s string) {
+	if p.lineStart {
+		p.out.WriteString(strings.Repeat("\t", p.indent))
+		p.lineStart = false
+	}
+	p.out.WriteString(s)
+	p.opened = false
+}
+
+func (p *printer) open(s string) {
+	p.write(s)
+	p.indent++
+	p.opened = true
+}
+
+func (p *printer) close(s string) {
+	p.indent--
+	p.startLine(0)
+	p.write(s)
+}
+
+// startLine ends the current line before the item at line is written,
+// keeping one blank line between them if the source has one.
+func (p *printer) startLine(line int) {
+	if p.out.Len() == 0 {
+		return
+	}
+	if !p.lineStart {
+		p.trimSpace()
+		p.out.WriteByte('\n')
+		p.lineStart = true
+		p.commented = false
+	}
+	if !p.opened && p.blankBefore(line) {
+		p.out.WriteByte('\n')
+	}
+}
+
+// trimSpace drops the spaces written at the end of the current line, as
+// after an operator that is followed by a comment.
+func (p *printer) trimSpace() {
+	p.out.Truncate(len(bytes.TrimRight(p.out.Bytes(), " ")))
+}
+
+func (p *printer) blankBefore(line int) bool {
+	return line >= 2 && line-2 < len(p.lines) && strings.TrimSpace(p.lines[line-2]) == ""
+}
+
+// flush writes the comments that come before line in the source. A
+// comment that followed code on its line still does, unless another one
+// already ends the current line; the rest get lines of their own. Items
+// without a position do not flush anything.
+func (p *printer) flush(line int) {
+	if line == 0 {
+		return
+	}
+	for len(p.comments) > 0 && p.comments[0].Line < line {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+		if p.trailing(comment) && !p.lineStart && !p.commented {
+			p.trimSpace()
+			p.write(" " + comment.Literal)
+			p.commented = true
+			continue
+		}
+		p.startLine(comment.Line)
+		p.write(comment.Literal)
+	}
+}
+
+func (p *printer) hasCommentsBefore(line int) bool {
+	return line != 0 && len(p.comments) > 0 && p.comments[0].Line < line
+}
+
+func (p *printer) trailing(comment token.Token) bool {
+	if comment.Line > len(p.lines) {
+		return false
+	}
+	text := p.lines[comment.Line-1]
+	if comment.Column-1 > len(text) {
+		return false
+	}
+	return strings.TrimSpace(text[:comment.Column-1]) != ""
+}
+
+func (p *printer) program(program *ast.Program) {
+	p.statements(program.Statements)
+	p.flush(math.MaxInt)
+	if p.out.Len() > 0 {
+		p.out.WriteByte('\n')
+	}
+}
+
+func (p *printer) statements(statements []ast.Statement) {
+	for i, stmt := range statements {
+		line := startLine(stmt)
+		p.flush(line)
+		p.startLine(line)
+		p.statement(stmt)
+
+		var next ast.Statement
+		if i+1 < len(statements) {
+			next = statements[i+1]
+		}
+		if needsSemicolon(stmt, next) {
+			p.write(";")
+		}
+	}
+}
+
+// needsSemicolon reports whether stmt is written with a semicolon. Only
+// expressions ending in a block go without, unless next would otherwise
+// be read as continuing them, as in a call or an infix expression.
+func needsSemicolon(stmt, next ast.Statement) bool {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return true
+	}
+	switch es.Expression.(type) {
+	case *ast.IfExpression, *ast.TryExpression, *ast.MatchExpression:
+	default:
+		return true
+	}
+	nextExpression, ok := next.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	pr := newPrinter()
+	pr.expression(nextExpression.Expression)
+	return strings.IndexAny(pr.out.String(), "([-") == 0
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let ")
+		if stmt.Pattern != nil {
+			p.pattern(stmt.Pattern)
+		} else {
+			p.write(stmt.Name.Value)
+		}
+		p.write(" = ")
+		p.expression(stmt.Value)
+	case *ast.ReturnStatement:
+		p.write("return")
+		if stmt.ReturnValue != nil {
+			p.write(" ")
+			p.expression(stmt.ReturnValue)
+		}
+	case *ast.ThrowStatement:
+		p.write("throw ")
+		p.expression(stmt.Value)
+	case *ast.ImportStatement:
+		p.write(`import "` + stmt.Path + `"`)
+		if stmt.Alias != nil {
+			p.write(" as " + stmt.Alias.Value)
+		}
+	case *ast.ExportStatement:
+		p.write("export ")
+		p.statement(stmt.Statement)
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression)
+	}
+}
+
+func (p *printer) block(block *ast.BlockStatement) {
+	if len(block.Statements) == 0 && !p.hasCommentsBefore(block.Rbrace.Line) {
+		p.write("{}")
+		return
+	}
+	p.open("{")
+	p.statements(block.Statements)
+	p.flush(block.Rbrace.Line)
+	p.close("}")
+}
+
+// precedence is how tightly expression binds, as the parser sees it.
+func precedence(expression ast.Expression) int {
+	switch expression := expression.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(token.TokenType(expression.Operator))
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.IntegerLiteral:
+		// Only built or optimized programs have negative literals, which
+		// read back as prefix expressions.
+		if expression.Value < 0 {
+			return parser.PREFIX
+		}
+	case *ast.FloatLiteral:
+		if expression.Value < 0 {
+			return parser.PREFIX
+		}
+	}
+	return parser.INDEX
+}
+
+// operand writes expression in parentheses if it binds less tightly than
+// min.
+func (p *printer) operand(expression ast.Expression, min int) {
+	if precedence(expression) < min {
+		p.write("(")
+		p.expression(expression)
+		p.write(")")
+		return
+	}
+	p.expression(expression)
+}
+
+func (p *printer) expression(expression ast.Expression) {
+	if line := startLine(expression); p.hasCommentsBefore(line) {
+		// Comments from inside an expression are kept there, so the rest
+		// of it continues on a new, further indented line.
+		p.indent++
+		defer func() { p.indent-- }()
+		p.flush(line)
+		p.startLine(line)
+	}
+	switch e := expression.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+	case *ast.IntegerLiteral:
+		p.write(strconv.FormatInt(e.Value, 10))
+	case *ast.FloatLiteral:
+		p.write(formatFloat(e.Value))
+	case *ast.StringLiteral:
+		p.write(`"` + e.Value + `"`)
+	case *ast.BooleanExpression:
+		p.write(strconv.FormatBool(e.Value))
+	case *ast.InterpolatedString:
+		p.write(`"`)
+		for _, part := range e.Parts {
+			if text, ok := part.(*ast.StringLiteral); ok && isStringText(text.Token.Type) {
+				p.write(text.Value)
+				continue
+			}
+			p.write("${")
+			p.expression(part)
+			p.write("}")
+		}
+		p.write(`"`)
+	case *ast.PrefixExpression:
+		p.write(e.Operator)
+		p.operand(e.Right, parser.PREFIX)
+	case *ast.InfixExpression:
+		prec := precedence(e)
+		p.operand(e.Left, prec)
+		p.write(" " + e.Operator + " ")
+		p.operand(e.Right, prec+1)
+	case *ast.CallExpression:
+		p.operand(e.Function, parser.CALL)
+		p.write("(")
+		p.expressions(e.Arguments)
+		p.write(")")
+	case *ast.IndexExpression:
+		p.operand(e.Left, parser.INDEX)
+		p.write("[")
+		p.expression(e.Index)
+		p.write("]")
+	case *ast.PropertyExpression:
+		p.operand(e.Left, parser.INDEX)
+		p.write("." + e.Property.Value)
+	case *ast.ArrayLiteral:
+		if !p.multiline(e.Token, e.Elements) {
+			p.write("[")
+			p.expressions(e.Elements)
+			p.write("]")
+			return
+		}
+		p.open("[")
+		for i, element := range e.Elements {
+			p.item(i, startLine(element))
+			p.expression(element)
+		}
+		p.close("]")
+	case *ast.HashLiteral:
+		if !p.multiline(e.Token, e.Keys) {
+			p.write("{")
+			for i := range e.Keys {
+				if i > 0 {
+					p.write(", ")
+				}
+				p.pair(e.Keys[i], e.Values[i])
+			}
+			p.write("}")
+			return
+		}
+		p.open("{")
+		for i := range e.Keys {
+			p.item(i, startLine(e.Keys[i]))
+			p.pair(e.Keys[i], e.Values[i])
+		}
+		p.close("}")
+	case *ast.FunctionLiteral:
+		p.write("fn(")
+		for i, param := range e.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(param)
+		}
+		p.write(") ")
+		p.block(e.Body)
+	case *ast.MacroLiteral:
+		p.write("macro(")
+		for i, param := range e.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(param.Value)
+		}
+		p.write(") ")
+		p.block(e.Body)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(e.Condition)
+		p.write(") ")
+		p.block(e.Consequence)
+		switch alternative := e.Alternative.(type) {
+		case *ast.IfExpression:
+			p.write(" else ")
+			p.expression(alternative)
+		case *ast.BlockStatement:
+			p.write(" else ")
+			p.block(alternative)
+		}
+	case *ast.TryExpression:
+		p.write("try ")
+		p.block(e.Block)
+		if e.Catch != nil {
+			p.write(" catch ")
+			if e.Param != nil {
+				p.write("(" + e.Param.Value + ") ")
+			}
+			p.block(e.Catch)
+		}
+		if e.Finally != nil {
+			p.write(" finally ")
+			p.block(e.Finally)
+		}
+	case *ast.MatchExpression:
+		p.write("match (")
+		p.expression(e.Subject)
+		p.write(") ")
+		p.open("{")
+		for i, arm := range e.Arms {
+			p.item(i, arm.Token.Line)
+			p.pattern(arm.Pattern)
+			if arm.Guard != nil {
+				p.write(" if ")
+				p.expression(arm.Guard)
+			}
+			p.write(" => ")
+			if block, ok := arm.Body.(*ast.BlockStatement); ok {
+				p.block(block)
+			} else if body := arm.Body.(ast.Expression); startsWithBrace(body) {
+				p.write("(")
+				p.expression(body)
+				p.write(")")
+			} else {
+				p.expression(body)
+			}
+		}
+		p.close("}")
+	}
+}
+
+// startsWithBrace reports whether expression is written starting with a
+// hash literal, which after => would be read as a block.
+func startsWithBrace(expression ast.Expression) bool {
+	pr := newPrinter()
+	pr.expression(expression)
+	return strings.HasPrefix(pr.out.String(), "{")
+}
+
+func (p *printer) expressions(expressions []ast.Expression) {
+	for i, expression := range expressions {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(expression)
+	}
+}
+
+func (p *printer) pair(key, value ast.Expression) {
+	p.expression(key)
+	p.write(": ")
+	p.expression(value)
+}
+
+// item starts the i-th item of a list written one item per line.
+func (p *printer) item(i, line int) {
+	if i > 0 {
+		p.write(",")
+	}
+	p.flush(line)
+	p.startLine(line)
+}
+
+// multiline reports whether a literal opened by open is written one item
+// per line, which it is when the source starts any item on a later line.
+func (p *printer) multiline(open token.Token, items []ast.Expression) bool {
+	if open.Line == 0 {
+		return false
+	}
+	for _, item := range items {
+		if startLine(item) > open.Line {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		p.write("_")
+	case *ast.BindingPattern:
+		p.write(pattern.Name.Value)
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value)
+	case *ast.AlternativePattern:
+		for i, alternative := range pattern.Alternatives {
+			if i > 0 {
+				p.write(" | ")
+			}
+			p.pattern(alternative)
+		}
+	case *ast.ArrayPattern:
+		p.write("[")
+		for i, element := range pattern.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(element)
+		}
+		if pattern.Rest != nil {
+			if len(pattern.Elements) > 0 {
+				p.write(", ")
+			}
+			p.write("..." + pattern.Rest.Value)
+		}
+		p.write("]")
+	case *ast.HashPattern:
+		p.write("{")
+		for i, key := range pattern.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			name, isName := key.(*ast.StringLiteral)
+			isName = isName && name.Token.Type == token.IDENT
+			if binding, ok := pattern.Values[i].(*ast.BindingPattern); ok && isName && binding.Name.Value == name.Value {
+				p.write(name.Value)
+				continue
+			}
+			if isName {
+				p.write(name.Value)
+			} else {
+				p.expression(key)
+			}
+			p.write(": ")
+			p.pattern(pattern.Values[i])
+		}
+		p.write("}")
+	}
+}
+
+// isStringText reports whether a string literal with a token of type t
+// is text of an interpolated string rather than an interpolated value.
+func isStringText(t token.TokenType) bool {
+	return t == token.INTERP_START || t == token.INTERP_MID || t == token.INTERP_END
+}
+
+// formatFloat writes f so that it lexes as a FLOAT again, which needs a
+// fraction.
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// startLine is the line node starts on in the source, or 0 if unknown.
+func startLine(node ast.Node) int {
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		if node.Expression != nil {
+			return startLine(node.Expression)
+		}
+	case *ast.InfixExpression:
+		return startLine(node.Left)
+	case *ast.CallExpression:
+		return startLine(node.Function)
+	case *ast.IndexExpression:
+		return startLine(node.Left)
+	case *ast.PropertyExpression:
+		return startLine(node.Left)
+	case *ast.BindingPattern:
+		return node.Name.Token.Line
+	}
+	// Every other node starts with its Token field.
+	v := reflect.Indirect(reflect.ValueOf(node))
+	if v.Kind() != reflect.Struct {
+		return 0
+	}
+	field := v.FieldByName("Token")
+	if !field.IsValid() {
+		return 0
+	}
+	tok, _ := field.Interface().(token.Token)
+	return tok.Line
+}
@@ -0,0 +1,203 @@
+package format
+
+import (
+	"errors"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/token"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let  x=5`, "let x = 5;\n"},
+		{`let add=fn(a,b){a+b};add(1,2)`, "let add = fn(a, b) {\n\ta + b;\n};\nadd(1, 2);\n"},
+		{`(5 + 5) * 2; 5 + (5 * 2); 5 - (3 - 1); (5 - 3) - 1`, "(5 + 5) * 2;\n5 + 5 * 2;\n5 - (3 - 1);\n5 - 3 - 1;\n"},
+		{`-(a + b); !(-a); (-a)[0]; -a[0]; (a + b)(c)`, "-(a + b);\n!-a;\n(-a)[0];\n-a[0];\n(a + b)(c);\n"},
+		{`a < b == (c > d)`, "a < b == c > d;\n"},
+		{`[1,2,3][0]; {"a":1, 2:true}; {}; []`, "[1, 2, 3][0];\n{\"a\": 1, 2: true};\n{};\n[];\n"},
+		{`let f = fn() {}`, "let f = fn() {};\n"},
+		{`if(x){1}else if(y){2}else{3}`, "if (x) {\n\t1;\n} else if (y) {\n\t2;\n} else {\n\t3;\n}\n"},
+		{`if (x) { 1 }; (a)`, "if (x) {\n\t1;\n}\na;\n"},
+		{`if (x) { 1 }; (a + b)(c)`, "if (x) {\n\t1;\n};\n(a + b)(c);\n"},
+		{`if (x) { 1 }; [1]`, "if (x) {\n\t1;\n};\n[1];\n"},
+		{`if (x) { 1 }; let y = 2`, "if (x) {\n\t1;\n}\nlet y = 2;\n"},
+		{`try{f()}catch{1}`, "try {\n\tf();\n} catch {\n\t1;\n}\n"},
+		{`try { f() } catch(e) { e.message } finally { g() }`,
+			"try {\n\tf();\n} catch (e) {\n\te.message;\n} finally {\n\tg();\n}\n"},
+		{`match(x){1|2=>"a",[h,...t] if h>0=>{h},{name,"age":a}=>a,-1=>0,_=>1,}`,
+			"match (x) {\n\t1 | 2 => \"a\",\n\t[h, ...t] if h > 0 => {\n\t\th;\n\t},\n\t{name, \"age\": a} => a,\n\t-1 => 0,\n\t_ => 1\n}\n"},
+		{`let [a,...b]=c; let {x:[y]}=z; fn([p],{q}){p}`, "let [a, ...b] = c;\nlet {x: [y]} = z;\nfn([p], {q}) {\n\tp;\n};\n"},
+		{`import "lib/util" as u; export let v=u.f(1)`, "import \"lib/util\" as u;\nexport let v = u.f(1);\n"},
+		{`let m = macro(a){quote(unquote(a))}`, "let m = macro(a) {\n\tquote(unquote(a));\n};\n"},
+		{`"a${b}c${"d"}"; 1.50; 2.0; throw "x"; return`, "\"a${b}c${\"d\"}\";\n1.5;\n2.0;\nthrow \"x\";\nreturn;\n"},
+		{"let h = {\"a\": 1,\n\"b\": 2}", "let h = {\n\t\"a\": 1,\n\t\"b\": 2\n};\n"},
+		{"let a = [1,\n\n 2]", "let a = [\n\t1,\n\n\t2\n];\n"},
+		{"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+		{"let f = fn() {\n\n  1\n\n};", "let f = fn() {\n\t1;\n};\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		out, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.input, err)
+			continue
+		}
+		if string(out) != tt.expected {
+			t.Errorf("%q: wrong output.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, out)
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// header\n\nlet x = 1; // one\n// before y\nlet y = 2;",
+			"// header\n\nlet x = 1; // one\n// before y\nlet y = 2;\n"},
+		{"let f = fn() { // opens\n  // first\n  1  //  after one\n  // last\n}",
+			"let f = fn() { // opens\n\t// first\n\t1; //  after one\n\t// last\n};\n"},
+		{"if (x) {\n// only a comment\n}", "if (x) {\n\t// only a comment\n}\n"},
+		{"let h = {\n  // a\n  \"a\": 1, // one\n  \"b\": 2\n};", "let h = {\n\t// a\n\t\"a\": 1, // one\n\t\"b\": 2\n};\n"},
+		{"match (x) {\n  // small\n  1 => 1, // one\n  _ => 2\n}", "match (x) {\n\t// small\n\t1 => 1, // one\n\t_ => 2\n}\n"},
+		{"1 // a // b\n// end", "1; // a // b\n// end\n"},
+		{"let s = \"not // a comment\";", "let s = \"not // a comment\";\n"},
+		{"// only\n", "// only\n"},
+		{"let x = 10 // after x\n\t/ 2; // slash", "let x = 10 / // after x\n\t2; // slash\n"},
+		{"let z = 1 +\n // mid\n 2;", "let z = 1 +\n\t// mid\n\t2;\n"},
+		{"let a = 1; // one\nlet b = 2 // two\n// three\n;", "let a = 1; // one\nlet b = 2; // two\n// three\n"},
+	}
+
+	for _, tt := range tests {
+		out, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.input, err)
+			continue
+		}
+		if string(out) != tt.expected {
+			t.Errorf("%q: wrong output.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, out)
+		}
+	}
+}
+
+// programs are formatted by TestSourceRoundTrip, which checks that the
+// output formats to itself and parses to the same program.
+var programs = []string{
+	`let fib = fn(n) { if (n < 2) { return n; }; fib(n - 1) + fib(n - 2) }; puts(fib(10));`,
+	`let a = [1, [2, 3], {"k": fn(x) { x * (x - 1) / 2 }}]; a[2]["k"](4) - -a[0]`,
+	"let h = {\n\"a\": [1,\n2], // c\n\"b\": {\"c\": 3}\n}; h.b.c",
+	`try { throw {"code": 1} } catch (e) { e.value.code } finally { puts("done") }; 1 + 2`,
+	`match ([1, 2]) { [a, ...r] | [a] if a == 1 => r, {x, "y": [_, z]} => z, "s" | true | 1.5 => 0, _ => null }`,
+	"import \"m\";\n\n// doc\nexport let [p, q] = m.pair(); // pair\n\n\nexport let r = fn({s}) {\n  // body\n  s\n};",
+	`let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) }; unless(false, 1, 2)`,
+	`if (a) { 1 } else { 2 }; -3; if (b) { 4 } (5)`,
+	`"x${1 + 2}y${"z"}"; ((a + b) * (c + d)) / (e - f); !(!a == b); f(g)(h)[i].j`,
+	`let f = fn() { }; let g = fn() { if (x) { } else { } }; try { } catch { }`,
+	"let x = 10 // after x\n\t/ 2; // slash\nlet z = f(1,\n // mid\n 2) +\n// end\n[3];",
+}
+
+func TestSourceRoundTrip(t *testing.T) {
+	for _, input := range programs {
+		out, err := Source([]byte(input))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", input, err)
+			continue
+		}
+		again, err := Source(out)
+		if err != nil {
+			t.Errorf("%q: output does not parse: %s\n%s", input, err, out)
+			continue
+		}
+		if string(again) != string(out) {
+			t.Errorf("%q: not idempotent.\nfirst:\n%s\nsecond:\n%s", input, out, again)
+		}
+		if parse(t, string(out)) != parse(t, input) {
+			t.Errorf("%q: output parses differently.\nexpected=%q\ngot=%q", input, parse(t, input), parse(t, string(out)))
+		}
+	}
+}
+
+func TestSourceMatchArmHash(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (1) { _ => ({"a": 1}) }`, "match (1) {\n\t_ => ({\"a\": 1})\n}\n"},
+		{`match (1) { _ => ({"a": 1})["a"] }`, "match (1) {\n\t_ => ({\"a\": 1}[\"a\"])\n}\n"},
+		{`match (1) { _ => [{}] }`, "match (1) {\n\t_ => [{}]\n}\n"},
+	}
+
+	for _, tt := range tests {
+		out, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", tt.input, err)
+		}
+		if string(out) != tt.expected {
+			t.Errorf("%q: wrong output.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, out)
+		}
+		if parse(t, string(out)) != parse(t, tt.input) {
+			t.Errorf("%q: output parses differently.\nexpected=%q\ngot=%q", tt.input, parse(t, tt.input), parse(t, string(out)))
+		}
+		again, err := Source(out)
+		if err != nil {
+			t.Fatalf("%q: output does not parse: %s", tt.input, err)
+		}
+		if string(again) != string(out) {
+			t.Errorf("%q: not idempotent.\nfirst:\n%s\nsecond:\n%s", tt.input, out, again)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) string {
+	t.Helper()
+	p := parser.MakeNewParser(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser errors: %q", input, p.Errors())
+	}
+	return program.String()
+}
+
+func TestSourceParseError(t *testing.T) {
+	_, err := Source([]byte("let = 1;"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got=%T (%v)", err, err)
+	}
+	if len(parseErr.Messages) == 0 || parseErr.Messages[0] != "expected next token to be IDENT, got = instead" {
+		t.Errorf("wrong messages. got=%q", parseErr.Messages)
+	}
+}
+
+func TestProgram(t *testing.T) {
+	// Nodes built by hand have no positions, so nothing is split over
+	// lines but blocks.
+	program := &ast.Program{Statements: []ast.Statement{
+		&ast.LetStatement{
+			Name: &ast.Identifier{Value: "x"},
+			Value: &ast.InfixExpression{
+				Operator: "*",
+				Left: &ast.InfixExpression{
+					Operator: "+",
+					Left:     &ast.IntegerLiteral{Value: 1},
+					Right:    &ast.FloatLiteral{Value: 2},
+				},
+				Right: &ast.ArrayLiteral{Elements: []ast.Expression{
+					&ast.StringLiteral{Token: token.Token{Type: token.STRING}, Value: "s"},
+					&ast.BooleanExpression{Value: true},
+				}},
+			},
+		},
+	}}
+
+	expected := "let x = (1 + 2.0) * [\"s\", true];\n"
+	if out := Program(program); out != expected {
+		t.Errorf("wrong output. expected=%q, got=%q", expected, out)
+	}
+}
@@ -0,0 +1,174 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"monkey/evaluator"
+	"monkey/object"
+	"reflect"
+)
+
+// ToObject converts a Go value into a Monkey object. Integers, floats,
+// strings, bools, nil, slices, arrays and maps are converted recursively;
+// object.Object values are returned as is. Functions shaped like
+// object.BuiltinFunction become builtins directly, any other function is
+// adapted as described for RegisterFunc.
+func (i *Interpreter) ToObject(value interface{}) (object.Object, error) {
+	switch v := value.(type) {
+	case nil:
+		return evaluator.NULL, nil
+	case object.Object:
+		return v, nil
+	case bool:
+		if v {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+	case string:
+		return &object.String{Value: v}, nil
+	case object.BuiltinFunction:
+		return &object.Builtin{Fn: v}, nil
+	case func(object.Runtime, ...object.Object) object.Object:
+		return &object.Builtin{Fn: v}, nil
+	case func(...object.Object) object.Object:
+		return &object.Builtin{Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+			return v(args...)
+		}}, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: rv.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("interpreter: %d overflows INTEGER", rv.Uint())
+		}
+		return &object.Integer{Value: int64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: rv.Float()}, nil
+	case reflect.String:
+		return &object.String{Value: rv.String()}, nil
+	case reflect.Bool:
+		return i.ToObject(rv.Bool())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		elements := make([]object.Object, rv.Len())
+		for idx := range elements {
+			element, err := i.ToObject(rv.Index(idx).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[idx] = element
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		hash := object.NewHash()
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := i.ToObject(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			hashKey, ok := key.(object.Hashable)
+			if !ok {
+				return nil, fmt.Errorf("interpreter: unusable as hash key: %s", key.Type())
+			}
+			value, err := i.ToObject(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(hashKey, value)
+		}
+		return hash, nil
+	case reflect.Func:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return i.adaptFunc("function", value)
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return i.ToObject(rv.Elem().Interface())
+	}
+	return nil, fmt.Errorf("interpreter: cannot convert %T to a Monkey object", value)
+}
+
+// FromObject converts a Monkey object into a Go value: INTEGER becomes
+// int64, FLOAT float64, STRING string, BOOLEAN bool, null nil, ARRAY
+// []interface{} and HASH map[string]interface{} when every key is a string, or
+// map[interface{}]interface{} otherwise. Functions and builtins become a
+// func(...interface{}) (interface{}, error) that calls back into the
+// interpreter, and errors become a *RuntimeError. Anything else is
+// returned unconverted.
+func (i *Interpreter) FromObject(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.NULL:
+		return nil
+	case *object.Array:
+		values := make([]interface{}, len(obj.Elements))
+		for idx, element := range obj.Elements {
+			values[idx] = i.FromObject(element)
+		}
+		return values
+	case *object.Hash:
+		return i.fromHash(obj)
+	case *object.Function, *object.Builtin:
+		return func(args ...interface{}) (interface{}, error) {
+			objs := make([]object.Object, len(args))
+			for idx, arg := range args {
+				converted, err := i.ToObject(arg)
+				if err != nil {
+					return nil, err
+				}
+				objs[idx] = converted
+			}
+			res, err := result(i.evaluator.ApplyContext(context.Background(), obj, objs...))
+			if err != nil {
+				return nil, err
+			}
+			return i.FromObject(res), nil
+		}
+	case *object.Error:
+		return &RuntimeError{Err: obj}
+	default:
+		return obj
+	}
+}
+
+func (i *Interpreter) fromHash(hash *object.Hash) interface{} {
+	stringKeys := true
+	for _, pair := range hash.Pairs {
+		if pair.Key.Type() != object.STRING_OBJ {
+			stringKeys = false
+			break
+		}
+	}
+	if stringKeys {
+		values := make(map[string]interface{}, len(hash.Pairs))
+		for _, pair := range hash.Pairs {
+			values[pair.Key.(*object.String).Value] = i.FromObject(pair.Value)
+		}
+		return values
+	}
+	values := make(map[interface{}]interface{}, len(hash.Pairs))
+	for _, pair := range hash.Pairs {
+		values[i.FromObject(pair.Key)] = i.FromObject(pair.Value)
+	}
+	return values
+}
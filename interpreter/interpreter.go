@@ -0,0 +1,186 @@
+// Package interpreter embeds Monkey in Go programs. An Interpreter owns
+// its global environment and builtins, so several can run side by side.
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+)
+
+// ParseError is returned when the source given to Run does not parse.
+type ParseError struct {
+	Messages []string
+}
+
+func (pe *ParseError) Error() string {
+	return "parse error: " + strings.Join(pe.Messages, "; ")
+}
+
+// RuntimeError is returned when a script evaluates to an *object.Error.
+type RuntimeError struct {
+	Err *object.Error
+}
+
+func (re *RuntimeError) Error() string {
+	return re.Err.Message
+}
+
+// StackTrace returns the error followed by the Monkey calls that led to
+// it, innermost first.
+func (re *RuntimeError) StackTrace() string {
+	return re.Err.StackTrace()
+}
+
+type Interpreter struct {
+	env       *object.Environment
+	macros    *object.Environment
+	evaluator *evaluator.Evaluator
+}
+
+// New returns an Interpreter with empty globals and its own copy of the
+// default builtins.
+func New() *Interpreter {
+	e := evaluator.New()
+	e.Builtins = make(map[string]*object.Builtin, len(evaluator.Builtins))
+	for name, builtin := range evaluator.Builtins {
+		e.Builtins[name] = builtin
+	}
+	return &Interpreter{env: object.NewEnvironment(nil), macros: object.NewEnvironment(nil), evaluator: e}
+}
+
+// SetOutput redirects puts, print and printf.
+func (i *Interpreter) SetOutput(w io.Writer) {
+	i.evaluator.Out = w
+}
+
+// RegisterBuiltin makes fn callable from scripts as name.
+func (i *Interpreter) RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	i.evaluator.Builtins[name] = &object.Builtin{Fn: fn, Name: name}
+}
+
+// SetMaxSteps limits how many AST nodes a single Run or Call may
+// evaluate. Zero, the default, means no limit.
+func (i *Interpreter) SetMaxSteps(n int) {
+	i.evaluator.MaxSteps = n
+}
+
+// SetMaxCallDepth limits how deeply Monkey functions may recurse.
+// It defaults to evaluator.DefaultMaxCallDepth; zero means no limit.
+func (i *Interpreter) SetMaxCallDepth(n int) {
+	i.evaluator.MaxCallDepth = n
+}
+
+// SetMaxMemory limits the approximate number of bytes of strings,
+// arrays, hashes and environments a single Run or Call may have in use at
+// once.
+// Zero, the default, means no limit.
+func (i *Interpreter) SetMaxMemory(bytes int64) {
+	i.evaluator.MaxMemory = bytes
+}
+
+// SetModulePath sets the directories import searches after the
+// importing module's own directory.
+func (i *Interpreter) SetModulePath(dirs ...string) {
+	i.evaluator.ModulePath = dirs
+}
+
+// SetClock replaces the clock used by the time module.
+func (i *Interpreter) SetClock(clock evaluator.Clock) {
+	i.evaluator.Clock = clock
+}
+
+// SeedRandom makes the random module deterministic.
+func (i *Interpreter) SeedRandom(seed int64) {
+	i.evaluator.Rand = rand.New(rand.NewSource(seed))
+}
+
+// PeakMemory reports roughly how many bytes the last Run or Call had in
+// use at most.
+func (i *Interpreter) PeakMemory() int64 {
+	return i.evaluator.PeakMemory()
+}
+
+// Run parses and evaluates src in the interpreter's global environment,
+// so bindings made by one Run are visible to the next. Macros are
+// expanded first, and those defined by one Run are available to the next
+// too.
+func (i *Interpreter) Run(src string) (object.Object, error) {
+	return i.RunContext(context.Background(), src)
+}
+
+// RunContext is like Run but aborts once ctx is done.
+func (i *Interpreter) RunContext(ctx context.Context, src string) (object.Object, error) {
+	p := parser.MakeNewParser(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Messages: p.Errors()}
+	}
+	evaluator.DefineMacros(program, i.macros)
+	expanded, err := i.evaluator.ExpandMacrosContext(ctx, program, i.macros)
+	if err != nil {
+		return result(err)
+	}
+	return result(i.evaluator.EvalContext(ctx, expanded, i.env))
+}
+
+// Call calls the global function or builtin fnName with args converted
+// by ToObject.
+func (i *Interpreter) Call(fnName string, args ...interface{}) (object.Object, error) {
+	return i.CallContext(context.Background(), fnName, args...)
+}
+
+// CallContext is like Call but aborts once ctx is done.
+func (i *Interpreter) CallContext(ctx context.Context, fnName string, args ...interface{}) (object.Object, error) {
+	fn, ok := i.Get(fnName)
+	if !ok {
+		return nil, fmt.Errorf("interpreter: %s is not defined", fnName)
+	}
+	objs := make([]object.Object, len(args))
+	for idx, arg := range args {
+		obj, err := i.ToObject(arg)
+		if err != nil {
+			return nil, err
+		}
+		objs[idx] = obj
+	}
+	return result(i.evaluator.ApplyContext(ctx, fn, objs...))
+}
+
+// Set binds the global name to value converted by ToObject.
+func (i *Interpreter) Set(name string, value interface{}) error {
+	obj, err := i.ToObject(value)
+	if err != nil {
+		return err
+	}
+	i.env.Set(name, obj)
+	return nil
+}
+
+// Get looks name up among the globals, then the builtins.
+func (i *Interpreter) Get(name string) (object.Object, bool) {
+	if obj, ok := i.env.Get(name); ok {
+		return obj, true
+	}
+	if builtin, ok := i.evaluator.Builtins[name]; ok {
+		return builtin, true
+	}
+	return nil, false
+}
+
+func result(obj object.Object) (object.Object, error) {
+	switch obj := obj.(type) {
+	case nil:
+		return evaluator.NULL, nil
+	case *object.Error:
+		return obj, &RuntimeError{Err: obj}
+	default:
+		return obj, nil
+	}
+}
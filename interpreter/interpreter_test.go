@@ -0,0 +1,311 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"monkey/object"
+	"reflect"
+	"testing"
+)
+
+func TestRunKeepsGlobals(t *testing.T) {
+	interp := New()
+
+	if _, err := interp.Run(`let add = fn(a, b) { a + b };`); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+	result, err := interp.Run(`add(2, 3)`)
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+	if result.Inspect() != "5" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Run(`let = 5;`)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got=%T (%v)", err, err)
+	}
+
+	_, err = interp.Run(`1 + true`)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected *RuntimeError, got=%T (%v)", err, err)
+	}
+	if runtimeErr.Error() != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error. got=%q", runtimeErr.Error())
+	}
+
+	_, err = interp.Run("let check = fn(x) {\n  x + true\n};\ncheck(1)")
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected *RuntimeError, got=%T (%v)", err, err)
+	}
+	expected := "ERROR: type mismatch: INTEGER + BOOLEAN\n" +
+		"    at check (line 2, column 5)\n" +
+		"    at <main> (line 4, column 6)"
+	if runtimeErr.StackTrace() != expected {
+		t.Errorf("wrong stack trace. expected=%q, got=%q", expected, runtimeErr.StackTrace())
+	}
+}
+
+func TestCallSetGet(t *testing.T) {
+	interp := New()
+
+	if err := interp.Set("greeting", "hello"); err != nil {
+		t.Fatalf("Set returned error: %s", err)
+	}
+	if _, err := interp.Run(`let greet = fn(name) { greeting + " " + name };`); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	result, err := interp.Call("greet", "monkey")
+	if err != nil {
+		t.Fatalf("Call returned error: %s", err)
+	}
+	if interp.FromObject(result) != "hello monkey" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+
+	result, err = interp.Call("len", []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("Call returned error: %s", err)
+	}
+	if interp.FromObject(result) != int64(3) {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+
+	if _, err := interp.Call("missing"); err == nil {
+		t.Errorf("expected error calling undefined function")
+	}
+	if _, err := interp.Call("greet", struct{}{}); err == nil {
+		t.Errorf("expected error converting struct argument")
+	}
+
+	greeting, ok := interp.Get("greeting")
+	if !ok || greeting.Inspect() != "hello" {
+		t.Errorf("Get returned %v, %t", greeting, ok)
+	}
+	if _, ok := interp.Get("missing"); ok {
+		t.Errorf("Get found undefined name")
+	}
+}
+
+func TestInterpretersAreIndependent(t *testing.T) {
+	first, second := New(), New()
+	first.RegisterBuiltin("answer", func(rt object.Runtime, args ...object.Object) object.Object {
+		return &object.Integer{Value: 42}
+	})
+	first.Set("x", 1)
+
+	if result, err := first.Run(`answer() + x`); err != nil || result.Inspect() != "43" {
+		t.Errorf("first interpreter got %v, %v", result, err)
+	}
+	if _, err := second.Run(`answer()`); err == nil {
+		t.Errorf("builtin leaked into second interpreter")
+	}
+	if _, err := second.Run(`x`); err == nil {
+		t.Errorf("global leaked into second interpreter")
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	var out bytes.Buffer
+	interp := New()
+	interp.SetOutput(&out)
+
+	if _, err := interp.Run(`puts("hi")`); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+	if out.String() != "hi\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestToObject(t *testing.T) {
+	interp := New()
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{42, "42"},
+		{uint8(7), "7"},
+		{2.5, "2.5"},
+		{float32(2), "2.0"},
+		{"str", "str"},
+		{true, "true"},
+		{[]int{1, 2}, "[1, 2]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{[]interface{}{1, "a", []bool{false}}, "[1, a, [false]]"},
+		{map[string]int{"b": 2, "a": 1}, "{a: 1, b: 2}"},
+		{map[int][]string{1: {"x"}}, "{1: [x]}"},
+		{&object.Integer{Value: 5}, "5"},
+	}
+
+	for _, tt := range tests {
+		obj, err := interp.ToObject(tt.input)
+		if err != nil {
+			t.Errorf("ToObject(%v) returned error: %s", tt.input, err)
+			continue
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("ToObject(%v) wrong. expected=%s, got=%s", tt.input, tt.expected, obj.Inspect())
+		}
+	}
+
+	if _, err := interp.ToObject(complex(1, 2)); err == nil {
+		t.Errorf("expected error converting complex128")
+	}
+	if _, err := interp.ToObject(map[float64]int{1.5: 1}); err == nil {
+		t.Errorf("expected error converting float64 keys")
+	}
+	if _, err := interp.ToObject(uint64(1 << 63)); err == nil {
+		t.Errorf("expected overflow error")
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	interp := New()
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`1`, int64(1)},
+		{`"a"`, "a"},
+		{`true`, true},
+		{`if (false) { 1 }`, nil},
+		{`[1, "a", [true]]`, []interface{}{int64(1), "a", []interface{}{true}}},
+		{`{"a": 1, "b": [2]}`, map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2)}}},
+		{`{1: "one", "two": 2}`, map[interface{}]interface{}{int64(1): "one", "two": int64(2)}},
+	}
+
+	for _, tt := range tests {
+		obj, err := interp.Run(tt.input)
+		if err != nil {
+			t.Fatalf("Run(%s) returned error: %s", tt.input, err)
+		}
+		value := interp.FromObject(obj)
+		if !reflect.DeepEqual(value, tt.expected) {
+			t.Errorf("FromObject(%s) wrong. expected=%#v, got=%#v", tt.input, tt.expected, value)
+		}
+	}
+}
+
+func TestFromObjectFunction(t *testing.T) {
+	interp := New()
+	obj, err := interp.Run(`fn(x, y) { [x + y, x] }`)
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	fn, ok := interp.FromObject(obj).(func(...interface{}) (interface{}, error))
+	if !ok {
+		t.Fatalf("FromObject did not return a func. got=%T", interp.FromObject(obj))
+	}
+	value, err := fn(1, 2)
+	if err != nil {
+		t.Fatalf("calling converted function returned error: %s", err)
+	}
+	if !reflect.DeepEqual(value, []interface{}{int64(3), int64(1)}) {
+		t.Errorf("wrong result. got=%#v", value)
+	}
+	if _, err := fn(1, "a"); err == nil {
+		t.Errorf("expected runtime error from converted function")
+	}
+}
+
+func TestRunContextLimits(t *testing.T) {
+	interp := New()
+	interp.SetMaxSteps(100)
+
+	_, err := interp.Run(`map(range(1000), fn(x) { x })`)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.STEP_LIMIT_ERR {
+		t.Fatalf("expected step limit error, got=%v", err)
+	}
+
+	interp.SetMaxSteps(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = interp.RunContext(ctx, `1`)
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.CANCELED_ERR {
+		t.Fatalf("expected canceled error, got=%v", err)
+	}
+	_, err = interp.CallContext(ctx, "len", "abc")
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.CANCELED_ERR {
+		t.Fatalf("expected canceled error, got=%v", err)
+	}
+
+	if result, err := interp.Run(`1 + 1`); err != nil || result.Inspect() != "2" {
+		t.Errorf("interpreter unusable after abort: %v, %v", result, err)
+	}
+}
+
+func TestMemoryQuota(t *testing.T) {
+	interp := New()
+	interp.SetMaxMemory(1 << 16)
+
+	_, err := interp.Run(`repeat("abc", 100000)`)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.MEMORY_LIMIT_ERR {
+		t.Fatalf("expected memory limit error, got=%v", err)
+	}
+	if interp.PeakMemory() <= 1<<16 {
+		t.Errorf("peak memory %d not above limit", interp.PeakMemory())
+	}
+
+	if _, err := interp.Run(`repeat("abc", 10)`); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+	if interp.PeakMemory() <= 0 || interp.PeakMemory() > 1<<16 {
+		t.Errorf("unexpected peak memory %d", interp.PeakMemory())
+	}
+}
+
+func TestCallDepthLimit(t *testing.T) {
+	interp := New()
+	interp.SetMaxCallDepth(50)
+
+	_, err := interp.Run(`let down = fn(n) { if (n == 0) { 0 } else { down(n - 1) } }; down(100)`)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Err.Kind != object.CALL_DEPTH_ERR {
+		t.Fatalf("expected call depth error, got=%v", err)
+	}
+	if runtimeErr.Error() != "maximum call depth exceeded (50): down (x50)" {
+		t.Errorf("wrong message. got=%q", runtimeErr.Error())
+	}
+
+	if result, err := interp.Call("down", 10); err != nil || result.Inspect() != "0" {
+		t.Errorf("Call within the limit failed: %v, %v", result, err)
+	}
+}
+
+func TestRunExpandsMacros(t *testing.T) {
+	interp := New()
+
+	if _, err := interp.Run(`let unless = macro(cond, then, other) { quote(if (!(unquote(cond))) { unquote(then) } else { unquote(other) }) };`); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+	result, err := interp.Run(`unless(1 > 2, "smaller", "greater")`)
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+	if result.Inspect() != "smaller" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+
+	_, err = interp.Run(`unless(true)`)
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected *RuntimeError, got=%T (%v)", err, err)
+	}
+	if runtimeErr.Error() != "wrong number of arguments: want=3, got=1" {
+		t.Errorf("wrong error. got=%q", runtimeErr.Error())
+	}
+}
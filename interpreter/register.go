@@ -0,0 +1,236 @@
+package interpreter
+
+import (
+	"fmt"
+	"monkey/object"
+	"reflect"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	objectType  = reflect.TypeOf((*object.Object)(nil)).Elem()
+	runtimeType = reflect.TypeOf((*object.Runtime)(nil)).Elem()
+)
+
+// RegisterFunc exposes the Go function fn to scripts as name. Arguments
+// are converted from Monkey objects to fn's parameter types, and results
+// back with ToObject. fn may return nothing, a value, an error, or a
+// value and an error; a non-nil error becomes an *object.Error. A first
+// parameter of type object.Runtime receives the calling evaluator, and
+// parameters of type object.Object receive the argument unconverted.
+func (i *Interpreter) RegisterFunc(name string, fn interface{}) error {
+	builtin, err := i.adaptFunc(name, fn)
+	if err != nil {
+		return err
+	}
+	builtin.Name = name
+	i.evaluator.Builtins[name] = builtin
+	return nil
+}
+
+func (i *Interpreter) adaptFunc(name string, fn interface{}) (*object.Builtin, error) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return nil, fmt.Errorf("interpreter: %s is %T, not a function", name, fn)
+	}
+	switch {
+	case ft.NumOut() > 2:
+		return nil, fmt.Errorf("interpreter: %s returns %d values, want at most 2", name, ft.NumOut())
+	case ft.NumOut() == 2 && ft.Out(1) != errorType:
+		return nil, fmt.Errorf("interpreter: second result of %s must be error, got %s", name, ft.Out(1))
+	}
+
+	passRuntime := ft.NumIn() > 0 && ft.In(0) == runtimeType
+	params := make([]reflect.Type, 0, ft.NumIn())
+	for idx := 0; idx < ft.NumIn(); idx++ {
+		if idx == 0 && passRuntime {
+			continue
+		}
+		params = append(params, ft.In(idx))
+	}
+	for idx, param := range params {
+		if err := checkConvertible(param, ft.IsVariadic() && idx == len(params)-1); err != nil {
+			return nil, fmt.Errorf("interpreter: %s: %s", name, err)
+		}
+	}
+
+	return &object.Builtin{Fn: func(rt object.Runtime, args ...object.Object) (res object.Object) {
+		defer func() {
+			if r := recover(); r != nil {
+				res = &object.Error{Message: fmt.Sprintf("%s panicked: %v", name, r)}
+			}
+		}()
+
+		in, errObj := i.convertArgs(name, params, ft.IsVariadic(), args)
+		if errObj != nil {
+			return errObj
+		}
+		if passRuntime {
+			in = append([]reflect.Value{reflect.ValueOf(&rt).Elem()}, in...)
+		}
+		return i.convertResults(fv.Call(in))
+	}}, nil
+}
+
+func (i *Interpreter) convertArgs(name string, params []reflect.Type, variadic bool, args []object.Object) ([]reflect.Value, *object.Error) {
+	fixed := len(params)
+	if variadic {
+		fixed--
+		if len(args) < fixed {
+			return nil, &object.Error{Message: fmt.Sprintf(
+				"wrong number of arguments. got=%d, want at least %d", len(args), fixed)}
+		}
+	} else if len(args) != fixed {
+		return nil, &object.Error{Message: fmt.Sprintf(
+			"wrong number of arguments. got=%d, want=%d", len(args), fixed)}
+	}
+
+	in := make([]reflect.Value, len(args))
+	for idx, arg := range args {
+		var typ reflect.Type
+		if idx < fixed {
+			typ = params[idx]
+		} else {
+			typ = params[len(params)-1].Elem()
+		}
+		value, err := i.fromObjectTo(arg, typ)
+		if err != nil {
+			return nil, &object.Error{Message: fmt.Sprintf(
+				"argument %d to `%s`: %s", idx+1, name, err)}
+		}
+		in[idx] = value
+	}
+	return in, nil
+}
+
+func (i *Interpreter) convertResults(out []reflect.Value) object.Object {
+	if len(out) > 0 && out[len(out)-1].Type() == errorType {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return &object.Error{Message: err.Error()}
+		}
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		obj, _ := i.ToObject(nil)
+		return obj
+	}
+	obj, err := i.ToObject(out[0].Interface())
+	if err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+	return obj
+}
+
+func checkConvertible(typ reflect.Type, variadic bool) error {
+	if variadic {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return nil
+	case reflect.Interface:
+		if typ.NumMethod() == 0 || typ == objectType {
+			return nil
+		}
+	case reflect.Slice:
+		return checkConvertible(typ.Elem(), false)
+	case reflect.Map:
+		if err := checkConvertible(typ.Key(), false); err != nil {
+			return err
+		}
+		return checkConvertible(typ.Elem(), false)
+	}
+	return fmt.Errorf("unsupported parameter type %s", typ)
+}
+
+// fromObjectTo converts obj to a Go value of type typ.
+func (i *Interpreter) fromObjectTo(obj object.Object, typ reflect.Type) (reflect.Value, error) {
+	if typ == objectType {
+		return reflect.ValueOf(&obj).Elem(), nil
+	}
+
+	value := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		integer, ok := obj.(*object.Integer)
+		if !ok {
+			return value, fmt.Errorf("must be INTEGER, got %s", obj.Type())
+		}
+		if value.OverflowInt(integer.Value) {
+			return value, fmt.Errorf("%d overflows %s", integer.Value, typ)
+		}
+		value.SetInt(integer.Value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		integer, ok := obj.(*object.Integer)
+		if !ok {
+			return value, fmt.Errorf("must be INTEGER, got %s", obj.Type())
+		}
+		if integer.Value < 0 || value.OverflowUint(uint64(integer.Value)) {
+			return value, fmt.Errorf("%d overflows %s", integer.Value, typ)
+		}
+		value.SetUint(uint64(integer.Value))
+	case reflect.Float32, reflect.Float64:
+		switch number := obj.(type) {
+		case *object.Integer:
+			value.SetFloat(float64(number.Value))
+		case *object.Float:
+			value.SetFloat(number.Value)
+		default:
+			return value, fmt.Errorf("must be FLOAT, got %s", obj.Type())
+		}
+	case reflect.String:
+		str, ok := obj.(*object.String)
+		if !ok {
+			return value, fmt.Errorf("must be STRING, got %s", obj.Type())
+		}
+		value.SetString(str.Value)
+	case reflect.Bool:
+		boolean, ok := obj.(*object.Boolean)
+		if !ok {
+			return value, fmt.Errorf("must be BOOLEAN, got %s", obj.Type())
+		}
+		value.SetBool(boolean.Value)
+	case reflect.Interface:
+		var converted interface{}
+		if obj.Type() != object.NULL_OBJ {
+			converted = i.FromObject(obj)
+		}
+		if converted != nil {
+			value.Set(reflect.ValueOf(converted))
+		}
+	case reflect.Slice:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			return value, fmt.Errorf("must be ARRAY, got %s", obj.Type())
+		}
+		value = reflect.MakeSlice(typ, len(arr.Elements), len(arr.Elements))
+		for idx, element := range arr.Elements {
+			converted, err := i.fromObjectTo(element, typ.Elem())
+			if err != nil {
+				return value, fmt.Errorf("element %d %s", idx, err)
+			}
+			value.Index(idx).Set(converted)
+		}
+	case reflect.Map:
+		hash, ok := obj.(*object.Hash)
+		if !ok {
+			return value, fmt.Errorf("must be HASH, got %s", obj.Type())
+		}
+		value = reflect.MakeMapWithSize(typ, len(hash.Pairs))
+		for _, pair := range hash.Pairs {
+			key, err := i.fromObjectTo(pair.Key, typ.Key())
+			if err != nil {
+				return value, fmt.Errorf("key %s", err)
+			}
+			elem, err := i.fromObjectTo(pair.Value, typ.Elem())
+			if err != nil {
+				return value, fmt.Errorf("value for %s %s", pair.Key.Inspect(), err)
+			}
+			value.SetMapIndex(key, elem)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,137 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"monkey/object"
+	"strings"
+	"testing"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	interp := New()
+	funcs := map[string]interface{}{
+		"add":   func(a, b int) int { return a + b },
+		"small": func(a int8) int8 { return a },
+		"nat":   func(a uint) uint { return a },
+		"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+		"not":   func(b bool) bool { return !b },
+		"sum": func(xs ...int) int {
+			n := 0
+			for _, x := range xs {
+				n += x
+			}
+			return n
+		},
+		"label": func(prefix string, xs ...int) string { return fmt.Sprint(prefix, xs) },
+		"names": func(m map[string]int) []string { return []string{fmt.Sprint(len(m))} },
+		"double": func(xs []int) []int {
+			for i := range xs {
+				xs[i] *= 2
+			}
+			return xs
+		},
+		"kind": func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"raw":  func(obj object.Object) string { return string(obj.Type()) },
+		"noop": func() {},
+		"div": func(a, b int) (int, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"check": func(ok bool) error {
+			if !ok {
+				return errors.New("check failed")
+			}
+			return nil
+		},
+		"boom": func() int { panic("kaboom") },
+		"apply": func(rt object.Runtime, fn object.Object, x int) object.Object {
+			return rt.Apply(fn, &object.Integer{Value: int64(x)})
+		},
+	}
+	for name, fn := range funcs {
+		if err := interp.RegisterFunc(name, fn); err != nil {
+			t.Fatalf("RegisterFunc(%s) returned error: %s", name, err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`add(1, 2)`, "3"},
+		{`add(1)`, "ERROR: wrong number of arguments. got=1, want=2"},
+		{`add(1, "2")`, "ERROR: argument 2 to `add`: must be INTEGER, got STRING"},
+		{`small(127)`, "127"},
+		{`small(128)`, "ERROR: argument 1 to `small`: 128 overflows int8"},
+		{`nat(-1)`, "ERROR: argument 1 to `nat`: -1 overflows uint"},
+		{`shout("hi")`, "HI!"},
+		{`not(true)`, "false"},
+		{`not(1)`, "ERROR: argument 1 to `not`: must be BOOLEAN, got INTEGER"},
+		{`sum()`, "0"},
+		{`sum(1, 2, 3)`, "6"},
+		{`sum(1, "2")`, "ERROR: argument 2 to `sum`: must be INTEGER, got STRING"},
+		{`label("xs")`, "xs[]"},
+		{`label("xs", 1, 2)`, "xs[1 2]"},
+		{`label()`, "ERROR: wrong number of arguments. got=0, want at least 1"},
+		{`names({"a": 1, "b": 2})`, "[2]"},
+		{`names({"a": "b"})`, "ERROR: argument 1 to `names`: value for a must be INTEGER, got STRING"},
+		{`double([1, 2])`, "[2, 4]"},
+		{`double([1, true])`, "ERROR: argument 1 to `double`: element 1 must be INTEGER, got BOOLEAN"},
+		{`kind(1)`, "int64"},
+		{`kind([1])`, "[]interface {}"},
+		{`kind(if (false) { 1 })`, "<nil>"},
+		{`raw([1])`, "ARRAY"},
+		{`noop()`, "null"},
+		{`div(6, 3)`, "2"},
+		{`div(1, 0)`, "ERROR: division by zero"},
+		{`check(true)`, "null"},
+		{`check(false)`, "ERROR: check failed"},
+		{`boom()`, "ERROR: boom panicked: kaboom"},
+		{`apply(fn(x) { x * 10 }, 4)`, "40"},
+	}
+
+	for _, tt := range tests {
+		result, _ := interp.Run(tt.input)
+		if result == nil || result.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestRegisterFuncRejectsBadSignatures(t *testing.T) {
+	interp := New()
+	tests := []struct {
+		fn       interface{}
+		expected string
+	}{
+		{42, "interpreter: bad is int, not a function"},
+		{func() (int, int) { return 0, 0 }, "interpreter: second result of bad must be error, got int"},
+		{func() (int, int, error) { return 0, 0, nil }, "interpreter: bad returns 3 values, want at most 2"},
+		{func(c complex128) {}, "interpreter: bad: unsupported parameter type complex128"},
+		{func(xs ...complex128) {}, "interpreter: bad: unsupported parameter type complex128"},
+	}
+
+	for _, tt := range tests {
+		err := interp.RegisterFunc("bad", tt.fn)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, err)
+		}
+	}
+}
+
+func TestToObjectAdaptsFunctions(t *testing.T) {
+	interp := New()
+	if err := interp.Set("inc", func(x int) int { return x + 1 }); err != nil {
+		t.Fatalf("Set returned error: %s", err)
+	}
+	result, err := interp.Run(`map([1, 2], inc)`)
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+	if result.Inspect() != "[2, 3]" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+}
@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"monkey/token"
+	"strings"
 	"testing"
 )
 
@@ -10,9 +11,30 @@ type Lexer struct {
 	position     int
 	readPosition int
 	ch           byte
+	// line and column locate ch, starting at 1.
+	line         int
+	column       int
+
+	// braces holds, for every "${" we are currently inside, how many
+	// unmatched '{' have been seen since it was opened.
+	braces []int
+
+	comments []token.Token
+}
+
+// Comments returns the COMMENT tokens skipped so far, in source order.
+// They are not passed on by NextToken, so only tools that reproduce the
+// source, such as a formatter, need to look at them.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -22,15 +44,37 @@ func (l *Lexer) readChar() {
 	l.readPosition += 1
 }
 
-func (l *Lexer) readString() string {
+// readString reads string text up to the closing '"' or up to the next
+// "${". It reports whether it stopped at an interpolation.
+func (l *Lexer) readString() (string, bool) {
 	position := l.position + 1
 	for {
 		l.readChar()
 		if l.ch == '"' || l.ch == 0 {
 			break
 		}
+		if l.ch == '$' && l.peekChar() == '{' {
+			str := l.input[position:l.position]
+			l.readChar()
+			l.braces = append(l.braces, 0)
+			return str, true
+		}
+	}
+	return l.input[position: l.position], false
+}
+
+func (l *Lexer) readInterpolatedString(start bool) token.Token {
+	str, interpolated := l.readString()
+	switch {
+	case interpolated && start:
+		return token.Token{Type: token.INTERP_START, Literal: str}
+	case interpolated:
+		return token.Token{Type: token.INTERP_MID, Literal: str}
+	case start:
+		return token.Token{Type: token.STRING, Literal: str}
+	default:
+		return token.Token{Type: token.INTERP_END, Literal: str}
 	}
-	return l.input[position: l.position]
 }
 
 func (l *Lexer) peekChar() byte {
@@ -41,7 +85,7 @@ func (l *Lexer) peekChar() byte {
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
@@ -66,6 +110,19 @@ func (l *Lexer) readDigit() string {
 	return l.input[position:l.position]
 }
 
+// readNumber reads an INT, or a FLOAT when the digits are followed by a
+// fraction such as 1.5.
+func (l *Lexer) readNumber() (token.TokenType, string) {
+	position := l.position
+	l.readDigit()
+	if l.ch != '.' || !isDigit(l.peekChar()) {
+		return token.INT, l.input[position:l.position]
+	}
+	l.readChar()
+	l.readDigit()
+	return token.FLOAT, l.input[position:l.position]
+}
+
 func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
@@ -84,11 +141,28 @@ func (l *Lexer) skipWhiteSpace() {
 	}
 }
 
+// skipComments skips whitespace and "//" comments up to the next token,
+// recording the comments.
+func (l *Lexer) skipComments() {
+	l.skipWhiteSpace()
+	for l.ch == '/' && l.peekChar() == '/' {
+		comment := token.Token{Type: token.COMMENT, Line: l.line, Column: l.column}
+		position := l.position
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+		comment.Literal = strings.TrimRight(l.input[position:l.position], " \t\r")
+		l.comments = append(l.comments, comment)
+		l.skipWhiteSpace()
+	}
+}
+
 func (l *Lexer) NextToken() token.Token {
 
 	var tok token.Token
 
-	l.skipWhiteSpace()
+	l.skipComments()
+	line, column := l.line, l.column
 
 	switch l.ch {
 	case '=':
@@ -96,14 +170,29 @@ func (l *Lexer) NextToken() token.Token {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.EQUAL, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: string(ch) + string(l.ch)}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if l.peekChar() == '.' && l.readPosition+1 < len(l.input) && l.input[l.readPosition+1] == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
+	case '|':
+		tok = newToken(token.PIPE, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readInterpolatedString(true)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -113,9 +202,20 @@ func (l *Lexer) NextToken() token.Token {
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '{':
+		if n := len(l.braces); n > 0 {
+			l.braces[n-1]++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
-		tok = newToken(token.RBRACE, l.ch)
+		if n := len(l.braces); n > 0 && l.braces[n-1] == 0 {
+			l.braces = l.braces[:n-1]
+			tok = l.readInterpolatedString(false)
+		} else {
+			if n > 0 {
+				l.braces[n-1]--
+			}
+			tok = newToken(token.RBRACE, l.ch)
+		}
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -145,10 +245,11 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readDigit()
-			tok.Type = token.INT
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -156,6 +257,7 @@ func (l *Lexer) NextToken() token.Token {
 	}
 
 	l.readChar()
+	tok.Line, tok.Column = line, column
 	return tok
 }
 
@@ -0,0 +1,523 @@
+// Package lint reports likely mistakes in Monkey programs without running
+// them: names that are not defined, bindings that are never used or that
+// shadow others, code after return or throw, calls with the wrong number
+// of arguments and if conditions that never change.
+package lint
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The checks a Diagnostic can come from.
+const (
+	Undefined         = "undefined"
+	Unused            = "unused"
+	Shadow            = "shadow"
+	Unreachable       = "unreachable"
+	Arity             = "arity"
+	ConstantCondition = "constant-condition"
+)
+
+// Diagnostic is a problem found at a position in the program.
+type Diagnostic struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", d.Line, d.Column, d.Message, d.Check)
+}
+
+// Program lints program, returning the diagnostics in source order. The
+// builtins and standard modules are defined, and so are the names in
+// globals, for hosts that define more. Unused top-level bindings are not
+// reported since hosts and importers may use them.
+func Program(program *ast.Program, globals ...string) []Diagnostic {
+	l := &linter{globals: map[string]bool{}}
+	for _, name := range globals {
+		l.globals[name] = true
+	}
+
+	l.statements(program.Statements, &scope{bindings: map[string]*binding{}})
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			l.unreachable(node.Statements)
+		case *ast.BlockStatement:
+			l.unreachable(node.Statements)
+		case *ast.IfExpression:
+			l.constantCondition(node)
+		}
+		return true
+	})
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diagnostics
+}
+
+type linter struct {
+	globals     map[string]bool
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(tok token.Token, check, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Check:   check,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// scope mirrors an environment of the evaluator: the program's, one for
+// the parameters and body of each function call, and one for each block,
+// catch clause and match arm.
+type scope struct {
+	outer    *scope
+	function bool
+	// report is set for scopes whose unused bindings are reported.
+	report   bool
+	bindings map[string]*binding
+}
+
+func newScope(outer *scope, function bool) *scope {
+	return &scope{outer: outer, function: function, report: true, bindings: map[string]*binding{}}
+}
+
+// Kinds of bindings.
+const (
+	variable  = "variable"
+	parameter = "parameter"
+	module    = "module"
+	pattern   = "pattern"
+)
+
+type binding struct {
+	ident *ast.Identifier
+	kind  string
+	// declared is false until the statement that binds the name is
+	// reached. Until then, only functions can refer to the binding, as
+	// they may be called after it.
+	declared bool
+	used     bool
+	// params is how many parameters the function or macro bound takes,
+	// or -1 if that is not known.
+	params int
+}
+
+// declare adds a binding for ident to s, reporting a shadowed name unless
+// it is only declared again in the same scope.
+func (l *linter) declare(s *scope, ident *ast.Identifier, kind string, params int) *binding {
+	if b, ok := s.bindings[ident.Value]; ok {
+		b.params = -1
+		return b
+	}
+	if (kind == variable || kind == parameter) && !strings.HasPrefix(ident.Value, "_") {
+		l.shadowed(s, ident)
+	}
+	b := &binding{ident: ident, kind: kind, params: params}
+	s.bindings[ident.Value] = b
+	return b
+}
+
+func (l *linter) shadowed(s *scope, ident *ast.Identifier) {
+	for outer := s.outer; outer != nil; outer = outer.outer {
+		if b, ok := outer.bindings[ident.Value]; ok {
+			l.report(ident.Token, Shadow, "%s shadows the declaration at line %d", ident.Value, b.ident.Token.Line)
+			return
+		}
+	}
+	if _, ok := evaluator.Builtins[ident.Value]; ok {
+		l.report(ident.Token, Shadow, "%s shadows the builtin %s", ident.Value, ident.Value)
+	} else if evaluator.IsStdModule(ident.Value) {
+		l.report(ident.Token, Shadow, "%s shadows the standard module %s", ident.Value, ident.Value)
+	}
+}
+
+// use resolves a reference to ident from s, reporting it if the name is
+// not defined.
+func (l *linter) use(s *scope, ident *ast.Identifier) *binding {
+	crossed, early := false, false
+	for ; s != nil; s = s.outer {
+		if b, ok := s.bindings[ident.Value]; ok {
+			if b.declared || crossed {
+				b.used = true
+				return b
+			}
+			early = true
+		}
+		if s.function {
+			crossed = true
+		}
+	}
+	if l.isGlobal(ident.Value) {
+		return nil
+	}
+	if early {
+		l.report(ident.Token, Undefined, "%s is used before it is declared", ident.Value)
+	} else {
+		l.report(ident.Token, Undefined, "undefined: %s", ident.Value)
+	}
+	return nil
+}
+
+func (l *linter) isGlobal(name string) bool {
+	_, builtin := evaluator.Builtins[name]
+	return builtin || evaluator.IsStdModule(name) || name == "quote" || l.globals[name]
+}
+
+// close reports the unused bindings of s once nothing more can use them.
+func (l *linter) close(s *scope) {
+	if !s.report {
+		return
+	}
+	for name, b := range s.bindings {
+		if b.used || strings.HasPrefix(name, "_") {
+			continue
+		}
+		switch b.kind {
+		case variable:
+			l.report(b.ident.Token, Unused, "%s is declared but not used", name)
+		case parameter:
+			l.report(b.ident.Token, Unused, "parameter %s is not used", name)
+		case module:
+			l.report(b.ident.Token, Unused, "module %s is imported but not used", name)
+		}
+	}
+}
+
+// statements lints a list of statements sharing s, first declaring what
+// they bind so that functions among them can refer to each other.
+func (l *linter) statements(statements []ast.Statement, s *scope) {
+	for _, stmt := range statements {
+		for _, b := range l.bindings(stmt) {
+			l.declare(s, b.ident, b.kind, b.params)
+		}
+	}
+	for _, stmt := range statements {
+		l.statement(stmt, s)
+	}
+}
+
+// bindings lists what stmt binds, not yet declared.
+func (l *linter) bindings(stmt ast.Statement) []*binding {
+	switch stmt := stmt.(type) {
+	case *ast.ExportStatement:
+		return l.bindings(stmt.Statement)
+	case *ast.LetStatement:
+		if stmt.Name == nil {
+			var bindings []*binding
+			for _, ident := range patternIdentifiers(stmt.Pattern) {
+				bindings = append(bindings, &binding{ident: ident, kind: variable, params: -1})
+			}
+			return bindings
+		}
+		params := -1
+		switch value := stmt.Value.(type) {
+		case *ast.FunctionLiteral:
+			params = len(value.Parameters)
+		case *ast.MacroLiteral:
+			params = len(value.Parameters)
+		}
+		return []*binding{{ident: stmt.Name, kind: variable, params: params}}
+	case *ast.ImportStatement:
+		ident := stmt.Alias
+		if ident == nil {
+			// Like the evaluator, bind the module to the base name of its path.
+			base := filepath.Base(stmt.Path)
+			ident = &ast.Identifier{Token: stmt.Token, Value: strings.TrimSuffix(base, filepath.Ext(base))}
+		}
+		return []*binding{{ident: ident, kind: module, params: -1}}
+	}
+	return nil
+}
+
+func (l *linter) statement(stmt ast.Statement, s *scope) {
+	switch stmt := stmt.(type) {
+	case *ast.ExportStatement:
+		l.statement(stmt.Statement, s)
+	case *ast.LetStatement:
+		l.expression(stmt.Value, s)
+		if stmt.Name != nil {
+			s.bindings[stmt.Name.Value].declared = true
+		}
+		for _, ident := range patternIdentifiers(stmt.Pattern) {
+			s.bindings[ident.Value].declared = true
+		}
+	case *ast.ImportStatement:
+		for _, b := range l.bindings(stmt) {
+			s.bindings[b.ident.Value].declared = true
+		}
+	case *ast.ReturnStatement:
+		l.expression(stmt.ReturnValue, s)
+	case *ast.ThrowStatement:
+		l.expression(stmt.Value, s)
+	case *ast.ExpressionStatement:
+		l.expression(stmt.Expression, s)
+	}
+}
+
+func (l *linter) block(block *ast.BlockStatement, s *scope) {
+	if block == nil {
+		return
+	}
+	bs := newScope(s, false)
+	l.statements(block.Statements, bs)
+	l.close(bs)
+}
+
+func (l *linter) expression(expression ast.Expression, s *scope) {
+	switch e := expression.(type) {
+	case *ast.Identifier:
+		l.use(s, e)
+	case *ast.PrefixExpression:
+		l.expression(e.Right, s)
+	case *ast.InfixExpression:
+		l.expression(e.Left, s)
+		l.expression(e.Right, s)
+	case *ast.IndexExpression:
+		l.expression(e.Left, s)
+		l.expression(e.Index, s)
+	case *ast.PropertyExpression:
+		l.expression(e.Left, s)
+	case *ast.InterpolatedString:
+		for _, part := range e.Parts {
+			l.expression(part, s)
+		}
+	case *ast.ArrayLiteral:
+		for _, element := range e.Elements {
+			l.expression(element, s)
+		}
+	case *ast.HashLiteral:
+		for i := range e.Keys {
+			l.expression(e.Keys[i], s)
+			l.expression(e.Values[i], s)
+		}
+	case *ast.CallExpression:
+		l.call(e, s)
+	case *ast.FunctionLiteral:
+		fs := newScope(s, true)
+		for _, param := range e.Parameters {
+			for _, ident := range patternIdentifiers(param) {
+				l.declare(fs, ident, parameter, -1).declared = true
+			}
+		}
+		l.statements(e.Body.Statements, fs)
+		l.close(fs)
+	case *ast.MacroLiteral:
+		fs := newScope(s, true)
+		for _, param := range e.Parameters {
+			l.declare(fs, param, parameter, -1).declared = true
+		}
+		l.statements(e.Body.Statements, fs)
+		l.close(fs)
+	case *ast.IfExpression:
+		l.expression(e.Condition, s)
+		l.block(e.Consequence, s)
+		switch alternative := e.Alternative.(type) {
+		case *ast.IfExpression:
+			l.expression(alternative, s)
+		case *ast.BlockStatement:
+			l.block(alternative, s)
+		}
+	case *ast.TryExpression:
+		l.block(e.Block, s)
+		cs := newScope(s, false)
+		if e.Param != nil {
+			l.declare(cs, e.Param, pattern, -1).declared = true
+		}
+		l.block(e.Catch, cs)
+		l.block(e.Finally, s)
+	case *ast.MatchExpression:
+		l.expression(e.Subject, s)
+		for _, arm := range e.Arms {
+			as := newScope(s, false)
+			for _, ident := range patternIdentifiers(arm.Pattern) {
+				l.declare(as, ident, pattern, -1).declared = true
+			}
+			l.expression(arm.Guard, as)
+			if block, ok := arm.Body.(*ast.BlockStatement); ok {
+				l.block(block, as)
+			} else if body, ok := arm.Body.(ast.Expression); ok {
+				l.expression(body, as)
+			}
+		}
+	}
+}
+
+func (l *linter) call(call *ast.CallExpression, s *scope) {
+	quoted := evaluator.IsQuote(call, func(name string) bool {
+		return l.isLocal(s, name) || l.globals[name]
+	})
+	if quoted {
+		// Quoted code is not run, except for what it unquotes.
+		for _, arg := range call.Arguments {
+			ast.Inspect(arg, func(node ast.Node) bool {
+				unquote, ok := node.(*ast.CallExpression)
+				if !ok || unquote.Function.TokenLiteral() != "unquote" {
+					return true
+				}
+				for _, arg := range unquote.Arguments {
+					l.expression(arg, s)
+				}
+				return false
+			})
+		}
+		return
+	}
+
+	l.expression(call.Function, s)
+	for _, arg := range call.Arguments {
+		l.expression(arg, s)
+	}
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	if b := l.lookup(s, ident.Value); b != nil && b.params >= 0 && b.params != len(call.Arguments) {
+		l.report(ident.Token, Arity, "wrong number of arguments to %s: want=%d, got=%d",
+			ident.Value, b.params, len(call.Arguments))
+	}
+}
+
+// lookup finds the binding a use of name from s resolved to, without
+// marking it used again.
+func (l *linter) lookup(s *scope, name string) *binding {
+	crossed := false
+	for ; s != nil; s = s.outer {
+		if b, ok := s.bindings[name]; ok && (b.declared || crossed) {
+			return b
+		}
+		if s.function {
+			crossed = true
+		}
+	}
+	return nil
+}
+
+func (l *linter) isLocal(s *scope, name string) bool {
+	return l.lookup(s, name) != nil
+}
+
+// patternIdentifiers lists the names pattern binds.
+func patternIdentifiers(p ast.Pattern) []*ast.Identifier {
+	var idents []*ast.Identifier
+	if p == nil {
+		return nil
+	}
+	ast.Inspect(p, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.BindingPattern:
+			idents = append(idents, node.Name)
+			return false
+		case *ast.ArrayPattern:
+			if node.Rest != nil {
+				idents = append(idents, node.Rest)
+			}
+		case *ast.LiteralPattern:
+			return false
+		case *ast.HashPattern:
+			for _, value := range node.Values {
+				idents = append(idents, patternIdentifiers(value)...)
+			}
+			return false
+		}
+		return true
+	})
+	return idents
+}
+
+// unreachable reports the first statement after a return or throw.
+func (l *linter) unreachable(statements []ast.Statement) {
+	for i, stmt := range statements[:maxInt(len(statements)-1, 0)] {
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.ThrowStatement:
+			l.report(statementToken(statements[i+1]), Unreachable, "unreachable code")
+			return
+		}
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.ThrowStatement:
+		return stmt.Token
+	case *ast.ImportStatement:
+		return stmt.Token
+	case *ast.ExportStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	}
+	return token.Token{}
+}
+
+func (l *linter) constantCondition(ie *ast.IfExpression) {
+	if !isConstant(ie.Condition) {
+		return
+	}
+	if truth, ok := truthiness(ie.Condition); ok {
+		l.report(ie.Token, ConstantCondition, "if condition is always %t", truth)
+		return
+	}
+	l.report(ie.Token, ConstantCondition, "if condition is constant")
+}
+
+// isConstant reports whether expression evaluates to the same value, or
+// fails the same way, every time.
+func isConstant(expression ast.Expression) bool {
+	switch e := expression.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.BooleanExpression:
+		return true
+	case *ast.PrefixExpression:
+		return isConstant(e.Right)
+	case *ast.InfixExpression:
+		return isConstant(e.Left) && isConstant(e.Right)
+	}
+	return false
+}
+
+// truthiness tells whether a constant expression is truthy, for the
+// expressions where that is plain to see.
+func truthiness(expression ast.Expression) (bool, bool) {
+	switch e := expression.(type) {
+	case *ast.BooleanExpression:
+		return e.Value, true
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+		// Everything but false and null is truthy.
+		return true, true
+	case *ast.PrefixExpression:
+		if e.Operator != "!" {
+			break
+		}
+		if truth, ok := truthiness(e.Right); ok {
+			return !truth, true
+		}
+	}
+	return false, false
+}
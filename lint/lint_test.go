@@ -0,0 +1,92 @@
+package lint
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func testLint(t *testing.T, input string, globals ...string) []string {
+	t.Helper()
+	p := parser.MakeNewParser(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser errors: %q", input, p.Errors())
+	}
+	var found []string
+	for _, d := range Program(program, globals...) {
+		found = append(found, d.String())
+	}
+	return found
+}
+
+func TestProgram(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`let x = 1; puts(x + len([]))`, nil},
+		{`puts(y)`, []string{"1:6: undefined: y (undefined)"}},
+		{`puts(x); let x = 1`, []string{"1:6: x is used before it is declared (undefined)"}},
+		{`let f = fn() { g() }; let g = fn() { 1 }; f()`, nil},
+		{`let f = fn(n) { if (n > 0) { f(n - 1) } }; f(1)`, nil},
+		{`let x = 1; let f = fn() { x; let x = 2; x }; f()`, []string{"1:34: x shadows the declaration at line 1 (shadow)"}},
+		{`let f = fn(a, b, _c) { a }; f(1, 2, 3)`, []string{"1:15: parameter b is not used (unused)"}},
+		{`let f = fn() { let [a, b] = [1, 2]; a }; f()`, []string{"1:24: b is declared but not used (unused)"}},
+		{`if (x) { let y = 1 }`, []string{
+			"1:5: undefined: x (undefined)",
+			"1:14: y is declared but not used (unused)",
+		}},
+		{`let unused = 1; export let used = 2`, nil},
+		{`let f = fn() { import "m" as m; 1 }; f()`, []string{"1:30: module m is imported but not used (unused)"}},
+		{`import "lib/util"; util.f(json.parse("1"))`, nil},
+		{`let f = fn(len) { len }; f(1)`, []string{"1:12: len shadows the builtin len (shadow)"}},
+		{`let f = fn() { let time = 1; time }; f()`, []string{"1:20: time shadows the standard module time (shadow)"}},
+		{`let f = fn(x) { fn(x) { x } }; f(1)`, []string{
+			"1:12: parameter x is not used (unused)",
+			"1:20: x shadows the declaration at line 1 (shadow)",
+		}},
+		{`let f = fn() { return 1; puts(2); puts(3) }; f()`, []string{"1:26: unreachable code (unreachable)"}},
+		{`if (true) { throw "e"; 1 }`, []string{
+			"1:1: if condition is always true (constant-condition)",
+			"1:24: unreachable code (unreachable)",
+		}},
+		{`let add = fn(a, b) { a + b }; add(1); add(1, 2); add(1, 2, 3)`, []string{
+			"1:31: wrong number of arguments to add: want=2, got=1 (arity)",
+			"1:50: wrong number of arguments to add: want=2, got=3 (arity)",
+		}},
+		{`let m = macro(a) { a }; m(1, 2)`, []string{"1:25: wrong number of arguments to m: want=1, got=2 (arity)"}},
+		{`let f = fn() { 1 }; let f = fn(a) { a }; f(1)`, nil},
+		{`if (!false) { 1 } else if (1 + 2 > "a") { 2 }`, []string{
+			"1:1: if condition is always true (constant-condition)",
+			"1:24: if condition is constant (constant-condition)",
+		}},
+		{`let x = 1; if (x) { 1 }`, nil},
+		{`match ([1]) { [h, ...t] if h > 0 => 1, {k} => 2, _ => 3 }`, nil},
+		{`match (1) { x => y }`, []string{"1:18: undefined: y (undefined)"}},
+		{`try { f() } catch (e) { 1 }`, []string{"1:7: undefined: f (undefined)"}},
+		{`let a = 1; quote(b + unquote(a) + unquote(c))`, []string{"1:43: undefined: c (undefined)"}},
+		{`let quote = fn(x) { x }; quote(b)`, []string{"1:32: undefined: b (undefined)"}},
+		{`let o = {"k": 1}; o.k.j`, nil},
+		{`let f = fn({a}, [b, ...c]) { a + b + len(c) }; f({"a": 1}, [2])`, nil},
+	}
+
+	for _, tt := range tests {
+		found := testLint(t, tt.input)
+		if len(found) != len(tt.expected) {
+			t.Errorf("%q: wrong diagnostics.\nexpected=%q\ngot=%q", tt.input, tt.expected, found)
+			continue
+		}
+		for i := range found {
+			if found[i] != tt.expected[i] {
+				t.Errorf("%q: wrong diagnostic %d. expected=%q, got=%q", tt.input, i, tt.expected[i], found[i])
+			}
+		}
+	}
+}
+
+func TestProgramGlobals(t *testing.T) {
+	if found := testLint(t, `host(1)`, "host"); len(found) != 0 {
+		t.Errorf("host global reported: %q", found)
+	}
+}
@@ -1,13 +1,34 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/format"
+	"monkey/lexer"
+	"monkey/lint"
+	"monkey/object"
+	"monkey/parser"
 	"monkey/repl"
 	"os"
 	"os/user"
+	"path/filepath"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(runFmt(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		}
+		os.Exit(runMain(os.Args[1:]))
+	}
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -16,3 +37,179 @@ func main() {
 	fmt.Printf("Feel free to type in commands\n")
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+// runMain runs the script named in args, after optimizing it with -O, or
+// prints the optimized script with -dump-optimized, and returns the exit
+// status.
+func runMain(args []string) int {
+	flags := flag.NewFlagSet("monkey", flag.ContinueOnError)
+	optimize := flags.Bool("O", false, "optimize the script before running it")
+	dump := flags.Bool("dump-optimized", false, "print the optimized script instead of running it")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: monkey [-O] [-dump-optimized] file")
+		fmt.Fprintln(os.Stderr, "       monkey fmt [-w] files...")
+		fmt.Fprintln(os.Stderr, "       monkey lint [-json] files...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	return runFile(flags.Arg(0), *optimize, *dump)
+}
+
+// runFile evaluates the script at path, resolving its imports relative
+// to its directory, and returns the exit status. With optimize, the
+// script is optimized once its macros are expanded; with dump, it is
+// then printed instead of evaluated.
+func runFile(path string, optimize, dump bool) int {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	p := parser.MakeNewParser(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, msg)
+		}
+		return 1
+	}
+
+	e := evaluator.New()
+	e.ModulePath = []string{filepath.Dir(path)}
+	macros := object.NewEnvironment(nil)
+	evaluator.DefineMacros(program, macros)
+	expanded, expandErr := e.ExpandMacros(program, macros)
+	if expandErr != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, expandErr.StackTrace())
+		return 1
+	}
+	if optimize || dump {
+		expanded = evaluator.Optimize(expanded.(*ast.Program))
+	}
+	if dump {
+		fmt.Print(format.Program(expanded.(*ast.Program)))
+		return 0
+	}
+	evaluated := e.Eval(expanded, object.NewEnvironment(nil))
+	if err, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err.StackTrace())
+		return 1
+	}
+	return 0
+}
+
+// runFmt formats the scripts named in args, printing them or, with -w,
+// rewriting those that change, and returns the exit status.
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: monkey fmt [-w] files...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		out, err := format.Source(src)
+		var parseErr *format.ParseError
+		if errors.As(err, &parseErr) {
+			for _, msg := range parseErr.Messages {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, msg)
+			}
+			status = 1
+			continue
+		}
+		if !*write {
+			os.Stdout.Write(out)
+			continue
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			err = os.WriteFile(path, out, info.Mode().Perm())
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// runLint lints the scripts named in args, printing what it finds as
+// text or, with -json, as a JSON array, and returns the exit status,
+// which is 1 if anything was found.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the diagnostics as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: monkey lint [-json] files...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	type fileDiagnostic struct {
+		File string `json:"file"`
+		lint.Diagnostic
+	}
+	status := 0
+	found := []fileDiagnostic{}
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		p := parser.MakeNewParser(lexer.New(string(src)))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			for _, msg := range p.Errors() {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, msg)
+			}
+			status = 1
+			continue
+		}
+		for _, d := range lint.Program(program) {
+			found = append(found, fileDiagnostic{File: path, Diagnostic: d})
+			status = 1
+		}
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(found, "", "  ")
+		fmt.Println(string(out))
+		return status
+	}
+	for _, d := range found {
+		fmt.Printf("%s:%s\n", d.File, d.Diagnostic)
+	}
+	return status
+}
@@ -2,21 +2,33 @@ package object
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"monkey/ast"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type ObjectType string
 const (
 	INTEGER_OBJ = "INTEGER"
+	FLOAT_OBJ = "FLOAT"
 	BOOLEAN_OBJ = "BOOLEAN"
 	STRING_OBJ = "STRING"
 	NULL_OBJ = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	ERROR_OBJ = "ERROR"
+	ERROR_VALUE_OBJ = "ERROR_VALUE"
 	FUNCTION_OBJ = "FUNCTION"
 	BUILTIN_OBJ = "BUILTIN"
+	ARRAY_OBJ = "ARRAY"
+	HASH_OBJ = "HASH"
+	MODULE_OBJ = "MODULE"
+	QUOTE_OBJ = "QUOTE"
+	MACRO_OBJ = "MACRO"
 )
 
 type Object interface {
@@ -24,10 +36,25 @@ type Object interface {
 	Inspect() string
 }
 
-type BuiltinFunction func(args ...Object) Object
+// Runtime is the evaluator a builtin is called from. Builtins use it to
+// call back into Monkey functions they were given as arguments.
+type Runtime interface {
+	Apply(fn Object, args ...Object) Object
+	// Writer is where output builtins such as puts write to.
+	Writer() io.Writer
+	// Context is done when the current run should stop.
+	Context() context.Context
+	// Allocate accounts for size bytes a builtin is about to allocate and
+	// returns an error once the run's memory quota is exceeded.
+	Allocate(size int64) *Error
+}
+
+type BuiltinFunction func(rt Runtime, args ...Object) Object
 
 type Builtin struct {
 	Fn BuiltinFunction
+	// Name is used in stack traces and may be empty.
+	Name string
 }
 func (b *Builtin) Type() ObjectType {
 	return BUILTIN_OBJ
@@ -36,8 +63,75 @@ func (b *Builtin) Inspect() string{
 	return "builtin function"
 }
 
+type ErrorKind string
+const (
+	// Errors that abort a run on behalf of the host. Scripts cannot
+	// catch them.
+	TIMEOUT_ERR = "TIMEOUT"
+	CANCELED_ERR = "CANCELED"
+	STEP_LIMIT_ERR = "STEP_LIMIT"
+	MEMORY_LIMIT_ERR = "MEMORY_LIMIT"
+	CALL_DEPTH_ERR = "CALL_DEPTH"
+
+	// Errors raised by a throw statement.
+	THROWN_ERR = "THROWN"
+)
+
 type Error struct {
 	Message string
+	// Kind is empty for ordinary runtime errors.
+	Kind ErrorKind
+	// Line and Column locate where the error was raised, or are zero
+	// if that is unknown.
+	Line int
+	Column int
+	// Value is the object given to throw, if any.
+	Value Object
+	// Stack lists the calls in progress when the error was raised,
+	// innermost first.
+	Stack []Frame
+}
+
+// Frame is one call in a stack trace: the function and the position
+// reached in it, which is zero when unknown.
+type Frame struct {
+	Function string
+	Line int
+	Column int
+}
+func (f Frame) String() string {
+	if f.Line == 0 {
+		return f.Function
+	}
+	return fmt.Sprintf("%s (line %d, column %d)", f.Function, f.Line, f.Column)
+}
+
+// maxTraceLines caps how many frames StackTrace prints.
+const maxTraceLines = 20
+
+// StackTrace formats the error followed by its frames, one per line.
+// Runs of identical frames, as left by deep recursion, are folded.
+func (e *Error) StackTrace() string {
+	var out bytes.Buffer
+	out.WriteString(e.Inspect())
+	lines := 0
+	for i := 0; i < len(e.Stack); {
+		if lines == maxTraceLines {
+			fmt.Fprintf(&out, "\n    ... %d more frames", len(e.Stack)-i)
+			break
+		}
+		j := i
+		for j < len(e.Stack) && e.Stack[j] == e.Stack[i] {
+			j++
+		}
+		out.WriteString("\n    at " + e.Stack[i].String())
+		if j-i > 1 {
+			fmt.Fprintf(&out, " (x%d)", j-i)
+		}
+		lines++
+		i = j
+	}
+	return out.String()
 }
 func (e *Error) Type() ObjectType {
 	return ERROR_OBJ
@@ -45,6 +139,27 @@ func (e *Error) Type() ObjectType {
 func (e *Error) Inspect() string {
 	return "ERROR: " + e.Message
 }
+// IsAbort reports whether e aborts the run rather than being catchable.
+func (e *Error) IsAbort() bool {
+	switch e.Kind {
+	case TIMEOUT_ERR, CANCELED_ERR, STEP_LIMIT_ERR, MEMORY_LIMIT_ERR, CALL_DEPTH_ERR:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrorValue is a caught Error. Unlike an Error it does not propagate, so
+// scripts can pass it around and inspect it.
+type ErrorValue struct {
+	Err *Error
+}
+func (ev *ErrorValue) Type() ObjectType {
+	return ERROR_VALUE_OBJ
+}
+func (ev *ErrorValue) Inspect() string {
+	return ev.Err.Inspect()
+}
 
 type ReturnValue struct {
 	Value Object
@@ -66,6 +181,22 @@ func (i *Integer) Inspect() string {
 	return fmt.Sprintf("%d", i.Value)
 }
 
+type Float struct {
+	Value	float64
+}
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+// Inspect always shows a fraction or exponent, so 2.0 is not mistaken
+// for the integer 2.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
 type String struct {
 	Value string
 }
@@ -76,6 +207,122 @@ func (s *String) Inspect() string {
 	return s.Value
 }
 
+type Array struct {
+	Elements []Object
+}
+func (a *Array) Type() ObjectType {
+	return ARRAY_OBJ
+}
+func (a *Array) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range a.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// Hashable is implemented by the objects that can be used as hash keys.
+type Hashable interface {
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+// Inspect lists the pairs sorted by key so that the output is stable.
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.SortedPairs() {
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+func (h *Hash) Set(key Hashable, value Object) {
+	h.Pairs[key.HashKey()] = HashPair{Key: key.(Object), Value: value}
+}
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair.Value, ok
+}
+// SortedPairs returns the pairs ordered by key type, then key.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		ki, kj := pairs[i].Key, pairs[j].Key
+		if ki.Type() != kj.Type() {
+			return ki.Type() < kj.Type()
+		}
+		if a, ok := ki.(*Integer); ok {
+			return a.Value < kj.(*Integer).Value
+		}
+		return ki.Inspect() < kj.Inspect()
+	})
+	return pairs
+}
+
+type Module struct {
+	Name	string
+	Path	string
+	Exports	map[string]Object
+}
+func (m *Module) Type() ObjectType {
+	return MODULE_OBJ
+}
+func (m *Module) Inspect() string {
+	return "<module " + m.Name + ">"
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -95,8 +342,13 @@ func (n *NULL) Inspect() string {
 }
 
 type Function struct {
-	Parameters 	[]*ast.Identifier
+	// Name is the name the function was first bound to with let, if any.
+	Name		string
+	Parameters 	[]ast.Pattern
 	Body		*ast.BlockStatement
+	// Scope lays out the environment of a call, if the function was
+	// resolved.
+	Scope		*ast.Scope
 	Env			*Environment
 }
 
@@ -121,23 +373,163 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// Quote is code returned by quote(), to be spliced into the program by
+// unquote() or by returning it from a macro.
+type Quote struct {
+	Node	ast.Node
+}
+
+func (q *Quote) Type() ObjectType {
+	return QUOTE_OBJ
+}
+func (q *Quote) Inspect() string {
+	return "QUOTE(" + q.Node.String() + ")"
+}
+
+type Macro struct {
+	Parameters	[]*ast.Identifier
+	Body		*ast.BlockStatement
+	Env			*Environment
+}
+
+func (m *Macro) Type() ObjectType {
+	return MACRO_OBJ
+}
+func (m *Macro) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range m.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("macro")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(m.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
+// Environment binds names to values. The environments of programs and
+// of code that was not resolved keep their bindings in a map. Those of
+// resolved functions, blocks, match arms and catch clauses keep them in
+// slots laid out by an ast.Scope, so identifiers the resolver located can
+// be looked up by index. Names that are not in the Scope still go in the
+// map.
 type Environment struct {
 	store map[string]Object
+	scope *ast.Scope
+	slots []Object
 	outer *Environment
 }
 func NewEnvironment(outer *Environment) *Environment {
 	s := make(map[string]Object)
 	return &Environment{store: s, outer: outer}
 }
+
+// NewScopedEnvironment makes an environment with a slot for each name in
+// scope, or a map-based one if scope is nil.
+func NewScopedEnvironment(outer *Environment, scope *ast.Scope) *Environment {
+	if scope == nil {
+		return NewEnvironment(outer)
+	}
+	return &Environment{scope: scope, slots: make([]Object, len(scope.Names)), outer: outer}
+}
+
+// Sibling makes an empty environment laid out like e, with the same outer
+// environment, so that it can bind the same identifiers e would.
+func (e *Environment) Sibling() *Environment {
+	if e.scope == nil {
+		return NewEnvironment(e.outer)
+	}
+	return NewScopedEnvironment(e.outer, e.scope)
+}
+
+// Adopt copies the bindings made directly in sibling, an environment
+// made by e.Sibling, into e.
+func (e *Environment) Adopt(sibling *Environment) {
+	for i, obj := range sibling.slots {
+		if obj != nil {
+			e.slots[i] = obj
+		}
+	}
+	for name, obj := range sibling.store {
+		e.Set(name, obj)
+	}
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+	obj, ok := e.getLocal(name)
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
 	return obj, ok
 }
 
+// getLocal looks name up in e alone. A slot that has not been assigned
+// yet does not count as a binding.
+func (e *Environment) getLocal(name string) (Object, bool) {
+	if e.scope != nil {
+		for i, n := range e.scope.Names {
+			if n == name && e.slots[i] != nil {
+				return e.slots[i], true
+			}
+		}
+	}
+	obj, ok := e.store[name]
+	return obj, ok
+}
+
 func (e *Environment) Set(name string, val Object) Object {
+	if e.scope != nil {
+		for i, n := range e.scope.Names {
+			if n == name {
+				e.slots[i] = val
+				return val
+			}
+		}
+		if e.store == nil {
+			e.store = make(map[string]Object)
+		}
+	}
 	e.store[name] = val
 	return val
-}
\ No newline at end of file
+}
+
+// Lookup finds the value ident refers to, by slot when the resolver
+// located its binding and by name otherwise. It falls back to looking the
+// name up when the environments are not laid out as the resolver
+// expected, so unresolved code evaluates the same either way.
+func (e *Environment) Lookup(ident *ast.Identifier) (Object, bool) {
+	env := e
+	for i := 0; i < ident.Depth && env.outer != nil; i++ {
+		env = env.outer
+	}
+	if ident.Scope == nil {
+		return env.Get(ident.Value)
+	}
+	if env.scope != ident.Scope {
+		return e.Get(ident.Value)
+	}
+	if obj := env.slots[ident.Slot]; obj != nil {
+		return obj, true
+	}
+	// The binding is not made yet, so the name means whatever it means
+	// further out, as it would if it were looked up by name.
+	if env.outer == nil {
+		return nil, false
+	}
+	return env.outer.Get(ident.Value)
+}
+
+// Bind binds ident in e, in its slot if the resolver gave it one there.
+func (e *Environment) Bind(ident *ast.Identifier, val Object) Object {
+	if ident.Scope != nil && ident.Scope == e.scope {
+		e.slots[ident.Slot] = val
+		return val
+	}
+	return e.Set(ident.Value, val)
+}
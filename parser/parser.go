@@ -36,6 +36,16 @@ var precedences = map[token.TokenType]int{
 	token.ASTERISK:  PRODUCT,
 	token.LPAREN: 	 CALL,
 	token.LBRACKET:	 INDEX,
+	token.DOT:		 INDEX,
+}
+
+// Precedence returns how tightly the infix operator t binds, or LOWEST
+// if t is not an infix operator.
+func Precedence(t token.TokenType) int {
+	if precedence, ok := precedences[t]; ok {
+		return precedence
+	}
+	return LOWEST
 }
 
 type Parser struct {
@@ -54,16 +64,22 @@ func MakeNewParser(lexer *lexer.Lexer) *Parser {
 	newParser.nextToken()
 	newParser.makePrefixFns = make(map[token.TokenType]makePrefixFn)
 	newParser.makePrefixFns[token.INT] = newParser.makeIntegerLiteral
+	newParser.makePrefixFns[token.FLOAT] = newParser.makeFloatLiteral
 	newParser.makePrefixFns[token.IDENT] = newParser.makeIdentifier
 	newParser.makePrefixFns[token.STRING] = newParser.makeStringLiteral
+	newParser.makePrefixFns[token.INTERP_START] = newParser.makeInterpolatedString
 	newParser.makePrefixFns[token.MINUS] = newParser.makePrefix
 	newParser.makePrefixFns[token.BANG] = newParser.makePrefix
 	newParser.makePrefixFns[token.TRUE] = newParser.makeBoolean
 	newParser.makePrefixFns[token.FALSE] = newParser.makeBoolean
 	newParser.makePrefixFns[token.LPAREN] = newParser.makeGroupExpression
 	newParser.makePrefixFns[token.IF] = newParser.makeIfExpression
+	newParser.makePrefixFns[token.TRY] = newParser.makeTryExpression
+	newParser.makePrefixFns[token.MATCH] = newParser.makeMatchExpression
 	newParser.makePrefixFns[token.FUNCTION] = newParser.makeFuncExpression
+	newParser.makePrefixFns[token.MACRO] = newParser.makeMacroLiteral
 	newParser.makePrefixFns[token.LBRACKET] = newParser.makeArrayLiteral
+	newParser.makePrefixFns[token.LBRACE] = newParser.makeHashLiteral
 	newParser.makeInfixFns = make(map[token.TokenType]makeInfixFn)
 	newParser.makeInfixFns[token.PLUS] = newParser.makeInfix
 	newParser.makeInfixFns[token.MINUS] = newParser.makeInfix
@@ -75,6 +91,7 @@ func MakeNewParser(lexer *lexer.Lexer) *Parser {
 	newParser.makeInfixFns[token.NOT_EQUAL] = newParser.makeInfix
 	newParser.makeInfixFns[token.LPAREN] = newParser.makeCallExpression
 	newParser.makeInfixFns[token.LBRACKET] = newParser.makeIndexExpression
+	newParser.makeInfixFns[token.DOT] = newParser.makePropertyExpression
 	return newParser
 }
 
@@ -114,10 +131,19 @@ func (p *Parser) checkNextToken(t token.TokenType) bool {
 func (p *Parser) makeLetStatement() *ast.LetStatement {
 	statement := &ast.LetStatement{Token: p.curToken}
 
-	if !p.checkNextToken(token.IDENT) {
-		return nil
+	switch p.peekToken.Type {
+	case token.LBRACKET, token.LBRACE:
+		p.nextToken()
+		statement.Pattern = p.parseSinglePattern()
+		if statement.Pattern == nil {
+			return nil
+		}
+	default:
+		if !p.checkNextToken(token.IDENT) {
+			return nil
+		}
+		statement.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	}
-	statement.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.checkNextToken(token.ASSIGN) {
 		return nil
@@ -132,11 +158,59 @@ func (p *Parser) makeLetStatement() *ast.LetStatement {
 	return statement
 }
 
+func (p *Parser) makeImportStatement() *ast.ImportStatement {
+	statement := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.checkNextToken(token.STRING) {
+		return nil
+	}
+	statement.Path = p.curToken.Literal
+
+	if p.peekToken.Type == token.AS {
+		p.nextToken()
+		if !p.checkNextToken(token.IDENT) {
+			return nil
+		}
+		statement.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if p.peekToken.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+	return statement
+}
+
+func (p *Parser) makeExportStatement() *ast.ExportStatement {
+	statement := &ast.ExportStatement{Token: p.curToken}
+
+	if !p.checkNextToken(token.LET) {
+		return nil
+	}
+	statement.Statement = p.makeLetStatement()
+	if statement.Statement == nil {
+		return nil
+	}
+	return statement
+}
+
 func (p *Parser) makeReturnStatement() *ast.ReturnStatement {
 	statement := &ast.ReturnStatement{Token: p.curToken}
 	p.nextToken()
 	statement.ReturnValue = p.makeExpression(LOWEST)
-	if p.curToken.Type == token.SEMICOLON {
+	if p.peekToken.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+	return statement
+}
+
+func (p *Parser) makeThrowStatement() *ast.ThrowStatement {
+	statement := &ast.ThrowStatement{Token: p.curToken}
+	p.nextToken()
+	statement.Value = p.makeExpression(LOWEST)
+	if statement.Value == nil {
+		return nil
+	}
+	if p.peekToken.Type == token.SEMICOLON {
 		p.nextToken()
 	}
 	return statement
@@ -172,10 +246,22 @@ func (p *Parser) makeIndexExpression(left ast.Expression) ast.Expression {
 	}
 	p.nextToken()
 	indexExp.Index = p.makeExpression(LOWEST)
+	if !p.checkNextToken(token.RBRACKET) {
+		return nil
+	}
 	return indexExp
 }
 
 
+func (p *Parser) makePropertyExpression(left ast.Expression) ast.Expression {
+	pe := &ast.PropertyExpression{Token: p.curToken, Left: left}
+	if !p.checkNextToken(token.IDENT) {
+		return nil
+	}
+	pe.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return pe
+}
+
 func (p *Parser) makeArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token:p.curToken}
 	p.nextToken()
@@ -191,6 +277,26 @@ func (p *Parser) makeArrayLiteral() ast.Expression {
 
 
 
+func (p *Parser) makeHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	for p.peekToken.Type != token.RBRACE {
+		p.nextToken()
+		key := p.makeExpression(LOWEST)
+		if !p.checkNextToken(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		value := p.makeExpression(LOWEST)
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+		if p.peekToken.Type != token.RBRACE && !p.checkNextToken(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+	return hash
+}
+
 func (p *Parser) makeCallExpression(function ast.Expression) ast.Expression {
 	call := &ast.CallExpression{Token: p.curToken, Function: function}
 	p.nextToken()
@@ -213,13 +319,15 @@ func (p *Parser) makeFuncExpression() ast.Expression {
 	p.nextToken()
 	p.nextToken()
 	if p.curToken.Type != token.RPAREN {
-		ident := &ast.Identifier{Token:p.curToken, Value: p.curToken.Literal}
-		function.Parameters = append(function.Parameters, ident)
+		if !p.makeParameter(function) {
+			return nil
+		}
 		p.nextToken()
 		for p.curToken.Type == token.COMMA {
 			p.nextToken()
-			ident = &ast.Identifier{Token:p.curToken, Value: p.curToken.Literal}
-			function.Parameters = append(function.Parameters, ident)
+			if !p.makeParameter(function) {
+				return nil
+			}
 			p.nextToken() 
 		}
 	}
@@ -234,6 +342,43 @@ func (p *Parser) makeFuncExpression() ast.Expression {
 	return function
 }
 
+// makeMacroLiteral parses a macro like a function literal, except that
+// its parameters cannot be destructured.
+func (p *Parser) makeMacroLiteral() ast.Expression {
+	tok := p.curToken
+	function, ok := p.makeFuncExpression().(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	macro := &ast.MacroLiteral{Token: tok, Body: function.Body}
+	for _, param := range function.Parameters {
+		binding, ok := param.(*ast.BindingPattern)
+		if !ok {
+			p.errors = append(p.errors, "macro parameters cannot be destructured")
+			return nil
+		}
+		macro.Parameters = append(macro.Parameters, binding.Name)
+	}
+	return macro
+}
+
+// makeParameter adds the parameter at the current token to function,
+// as a BindingPattern if it is a plain name.
+func (p *Parser) makeParameter(function *ast.FunctionLiteral) bool {
+	switch p.curToken.Type {
+	case token.LBRACKET, token.LBRACE:
+		pattern := p.parseSinglePattern()
+		if pattern == nil {
+			return false
+		}
+		function.Parameters = append(function.Parameters, pattern)
+	default:
+		ident := &ast.Identifier{Token:p.curToken, Value: p.curToken.Literal}
+		function.Parameters = append(function.Parameters, &ast.BindingPattern{Name: ident})
+	}
+	return true
+}
+
 func (p *Parser) makeIfExpression() ast.Expression {
 	ie := &ast.IfExpression{Token: p.curToken}
 	if p.peekToken.Type != token.LPAREN {
@@ -253,6 +398,15 @@ func (p *Parser) makeIfExpression() ast.Expression {
 	ie.Consequence = p.parseBlockStatement()
 	if p.peekToken.Type == token.ELSE {
 		p.nextToken()
+		if p.peekToken.Type == token.IF {
+			p.nextToken()
+			alternative := p.makeIfExpression()
+			if alternative == nil {
+				return nil
+			}
+			ie.Alternative = alternative
+			return ie
+		}
 		if p.peekToken.Type != token.LBRACE {
 			return nil
 		}
@@ -262,6 +416,213 @@ func (p *Parser) makeIfExpression() ast.Expression {
 	return ie
 }
 
+func (p *Parser) makeTryExpression() ast.Expression {
+	te := &ast.TryExpression{Token: p.curToken}
+	if !p.checkNextToken(token.LBRACE) {
+		return nil
+	}
+	te.Block = p.parseBlockStatement()
+
+	if p.peekToken.Type == token.CATCH {
+		p.nextToken()
+		if p.peekToken.Type == token.LPAREN {
+			p.nextToken()
+			if !p.checkNextToken(token.IDENT) {
+				return nil
+			}
+			te.Param = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.checkNextToken(token.RPAREN) {
+				return nil
+			}
+		}
+		if !p.checkNextToken(token.LBRACE) {
+			return nil
+		}
+		te.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekToken.Type == token.FINALLY {
+		p.nextToken()
+		if !p.checkNextToken(token.LBRACE) {
+			return nil
+		}
+		te.Finally = p.parseBlockStatement()
+	}
+
+	if te.Catch == nil && te.Finally == nil {
+		p.errors = append(p.errors, "expected catch or finally after try block")
+		return nil
+	}
+	return te
+}
+
+func (p *Parser) makeMatchExpression() ast.Expression {
+	me := &ast.MatchExpression{Token: p.curToken}
+	if !p.checkNextToken(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	me.Subject = p.makeExpression(LOWEST)
+	if !p.checkNextToken(token.RPAREN) {
+		return nil
+	}
+	if !p.checkNextToken(token.LBRACE) {
+		return nil
+	}
+
+	for p.peekToken.Type != token.RBRACE {
+		p.nextToken()
+		arm := &ast.MatchArm{Token: p.curToken}
+		arm.Pattern = p.parsePattern()
+		if arm.Pattern == nil {
+			return nil
+		}
+		if p.peekToken.Type == token.IF {
+			p.nextToken()
+			p.nextToken()
+			arm.Guard = p.makeExpression(LOWEST)
+		}
+		if !p.checkNextToken(token.ARROW) {
+			return nil
+		}
+		p.nextToken()
+		if p.curToken.Type == token.LBRACE {
+			arm.Body = p.parseBlockStatement()
+		} else if body := p.makeExpression(LOWEST); body != nil {
+			arm.Body = body
+		} else {
+			p.errors = append(p.errors, fmt.Sprintf("expected expression after =>, got %s", p.curToken.Literal))
+			return nil
+		}
+		me.Arms = append(me.Arms, arm)
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.checkNextToken(token.RBRACE) {
+		return nil
+	}
+	return me
+}
+
+// parsePattern parses a pattern starting at the current token, including
+// alternatives separated by |.
+func (p *Parser) parsePattern() ast.Pattern {
+	first := p.parseSinglePattern()
+	if first == nil || p.peekToken.Type != token.PIPE {
+		return first
+	}
+	ap := &ast.AlternativePattern{Token: p.peekToken, Alternatives: []ast.Pattern{first}}
+	for p.peekToken.Type == token.PIPE {
+		p.nextToken()
+		p.nextToken()
+		alternative := p.parseSinglePattern()
+		if alternative == nil {
+			return nil
+		}
+		ap.Alternatives = append(ap.Alternatives, alternative)
+	}
+	return ap
+}
+
+func (p *Parser) parseSinglePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.BindingPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE:
+		return &ast.LiteralPattern{Token: p.curToken, Value: p.makePrefixFns[p.curToken.Type]()}
+	case token.MINUS:
+		if p.peekToken.Type == token.INT || p.peekToken.Type == token.FLOAT {
+			pe := &ast.PrefixExpression{Token: p.curToken, Operator: p.curToken.Literal}
+			p.nextToken()
+			pe.Right = p.makePrefixFns[p.curToken.Type]()
+			return &ast.LiteralPattern{Token: pe.Token, Value: pe}
+		}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	}
+	p.errors = append(p.errors, fmt.Sprintf("unexpected %s in pattern", p.curToken.Literal))
+	return nil
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	ap := &ast.ArrayPattern{Token: p.curToken}
+	for p.peekToken.Type != token.RBRACKET {
+		p.nextToken()
+		if p.curToken.Type == token.ELLIPSIS {
+			if !p.checkNextToken(token.IDENT) {
+				return nil
+			}
+			ap.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
+		element := p.parsePattern()
+		if element == nil {
+			return nil
+		}
+		ap.Elements = append(ap.Elements, element)
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.checkNextToken(token.RBRACKET) {
+		return nil
+	}
+	return ap
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	hp := &ast.HashPattern{Token: p.curToken}
+	for p.peekToken.Type != token.RBRACE {
+		p.nextToken()
+		var key ast.Expression
+		switch p.curToken.Type {
+		case token.IDENT:
+			key = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+		case token.STRING, token.INT, token.TRUE, token.FALSE:
+			key = p.makePrefixFns[p.curToken.Type]()
+		default:
+			p.errors = append(p.errors, fmt.Sprintf("unexpected %s as hash pattern key", p.curToken.Literal))
+			return nil
+		}
+
+		var value ast.Pattern
+		if p.peekToken.Type == token.COLON {
+			p.nextToken()
+			p.nextToken()
+			value = p.parsePattern()
+			if value == nil {
+				return nil
+			}
+		} else if p.curToken.Type == token.IDENT {
+			// {name} is short for {name: name}.
+			value = &ast.BindingPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		} else {
+			p.checkNextToken(token.COLON)
+			return nil
+		}
+		hp.Keys = append(hp.Keys, key)
+		hp.Values = append(hp.Values, value)
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.checkNextToken(token.RBRACE) {
+		return nil
+	}
+	return hp
+}
+
 func (p *Parser) makeIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
@@ -270,6 +631,33 @@ func (p *Parser) makeStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value:p.curToken.Literal}
 }
 
+func (p *Parser) makeInterpolatedString() ast.Expression {
+	is := &ast.InterpolatedString{Token: p.curToken}
+
+	for {
+		if p.curToken.Literal != "" {
+			is.Parts = append(is.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		}
+		if p.curToken.Type == token.INTERP_END {
+			return is
+		}
+		p.nextToken()
+		part := p.makeExpression(LOWEST)
+		if part == nil {
+			msg := fmt.Sprintf("expected expression in string interpolation, got %s instead", p.curToken.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		is.Parts = append(is.Parts, part)
+		p.nextToken()
+		if p.curToken.Type != token.INTERP_MID && p.curToken.Type != token.INTERP_END {
+			msg := fmt.Sprintf("expected } to close string interpolation, got %s instead", p.curToken.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+	}
+}
+
 func (p *Parser) makeBoolean() ast.Expression {
 	return &ast.BooleanExpression{Token: p.curToken, Value: p.curToken.Type == token.TRUE}
 }
@@ -299,6 +687,19 @@ func (p *Parser) makeIntegerLiteral() ast.Expression {
 	return il
 }
 
+func (p *Parser) makeFloatLiteral() ast.Expression {
+	fl := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	fl.Value = value
+	return fl
+}
+
 func (p *Parser) makeExpression(precedence int) ast.Expression {
 	makePrefixFn := p.makePrefixFns[p.curToken.Type]
 	if makePrefixFn == nil {
@@ -338,6 +739,12 @@ func (p *Parser) makeStatement() ast.Statement {
 		statement = p.makeLetStatement()
 	case token.RETURN:
 		statement = p.makeReturnStatement()
+	case token.IMPORT:
+		statement = p.makeImportStatement()
+	case token.EXPORT:
+		statement = p.makeExportStatement()
+	case token.THROW:
+		statement = p.makeThrowStatement()
 	default:
 		statement = p.makeExpressionStatement()
 	}
@@ -365,10 +772,16 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	p.nextToken()
 	for p.curToken.Type != token.RBRACE && p.curToken.Type != token.EOF {
 		statement := p.makeStatement()
+		if export, ok := statement.(*ast.ExportStatement); ok && export != nil {
+			// Only the top level of a module has exports.
+			p.errors = append(p.errors, "export is only allowed at the top level")
+			statement = nil
+		}
 		if statement != nil {
 			blockStatement.Statements = append(blockStatement.Statements, statement)
 		}
 		p.nextToken()
 	}
+	blockStatement.Rbrace = p.curToken
 	return blockStatement
 }
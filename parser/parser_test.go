@@ -185,6 +185,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a * add(1, 2) * 2",
 			"((a * add(1, 2)) * 2)",
 		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"-m.x * m.f(1).y",
+			"((-(m.x)) * ((m.f)(1).y))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -314,11 +326,21 @@ func TestFunctionParameterParsing(t *testing.T) {
 		}
 
 		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
+			testParameter(t, function.Parameters[i], ident)
 		}
 	}
 }
 
+// testParameter checks that param is a plain parameter called name.
+func testParameter(t *testing.T, param ast.Pattern, name string) bool {
+	binding, ok := param.(*ast.BindingPattern)
+	if !ok {
+		t.Errorf("parameter is not ast.BindingPattern. got=%T", param)
+		return false
+	}
+	return testLiteralExpression(t, binding.Name, name)
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `fn(x,y) { x + y; }`
 
@@ -349,8 +371,8 @@ func TestFunctionLiteralParsing(t *testing.T) {
 		len(function.Parameters))
 	}
 
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
+	testParameter(t, function.Parameters[0], "x")
+	testParameter(t, function.Parameters[1], "y")
 
 	if len(function.Body.Statements) != 1 {
 		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
@@ -414,6 +436,46 @@ func TestIfExpression(t *testing.T) {
 	}
 }
 
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < 0) { a } else if (x == 0) { b } else if (x < 10) { c } else { d }`
+
+	parser := MakeNewParser(lexer.New(input))
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	exp, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("expression is not ast.IfExpression. got=%T", program.Statements[0])
+	}
+
+	conditions := []string{"(x < 0)", "(x == 0)", "(x < 10)"}
+	for i, condition := range conditions {
+		if exp.Condition.String() != condition {
+			t.Errorf("condition %d wrong. expected=%s, got=%s", i, condition, exp.Condition.String())
+		}
+		if i == len(conditions)-1 {
+			break
+		}
+		next, ok := exp.Alternative.(*ast.IfExpression)
+		if !ok {
+			t.Fatalf("alternative %d is not ast.IfExpression. got=%T", i, exp.Alternative)
+		}
+		exp = next
+	}
+	block, ok := exp.Alternative.(*ast.BlockStatement)
+	if !ok {
+		t.Fatalf("last alternative is not ast.BlockStatement. got=%T", exp.Alternative)
+	}
+	if block.String() != "d" {
+		t.Errorf("last alternative wrong. got=%s", block.String())
+	}
+
+	expected := "if(x < 0) a else if(x == 0) b else if(x < 10) c else d"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5)"
 
@@ -763,4 +825,498 @@ func TestParsingIndexExpression(t *testing.T) {
 	if !testInfixExpression(t, index.Index, 1, "+", 1) {
 		return
 	}
-}
\ No newline at end of file
+}
+func TestInterpolatedStringParsing(t *testing.T) {
+	input := `"hello ${name}, you have ${n + 1} items"`
+	lexer := lexer.New(input)
+	parser := MakeNewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	is, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+	if len(is.Parts) != 5 {
+		t.Fatalf("len(is.Parts) not 5. got=%d", len(is.Parts))
+	}
+
+	for i, expected := range []string{"hello ", ", you have ", " items"} {
+		literal, ok := is.Parts[i*2].(*ast.StringLiteral)
+		if !ok {
+			t.Fatalf("is.Parts[%d] not *ast.StringLiteral. got=%T", i*2, is.Parts[i*2])
+		}
+		if literal.Value != expected {
+			t.Errorf("literal.Value not %q. got=%q", expected, literal.Value)
+		}
+	}
+	testIdentifier(t, is.Parts[1], "name")
+	testInfixExpression(t, is.Parts[3], "n", "+", 1)
+
+	expected := "hello ${name}, you have ${(n + 1)} items"
+	if is.String() != expected {
+		t.Errorf("is.String() wrong. expected=%q, got=%q", expected, is.String())
+	}
+}
+
+func TestNestedInterpolatedStringParsing(t *testing.T) {
+	input := `"a ${ fn(x) { x }("b ${c} d") } e"; 1`
+	lexer := lexer.New(input)
+	parser := MakeNewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+	expected := "a ${fn(x)x(b ${c} d)} e"
+	if program.Statements[0].String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, program.Statements[0].String())
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]int64
+	}{
+		{`{}`, map[string]int64{}},
+		{`{"one": 1, "two": 2, "three": 3}`, map[string]int64{"one": 1, "two": 2, "three": 3}},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.New(tt.input)
+		parser := MakeNewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		hash, ok := stmt.Expression.(*ast.HashLiteral)
+		if !ok {
+			t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+		}
+		if len(hash.Keys) != len(tt.expected) || len(hash.Values) != len(tt.expected) {
+			t.Fatalf("hash has wrong number of pairs. got=%d", len(hash.Keys))
+		}
+		for i, key := range hash.Keys {
+			literal, ok := key.(*ast.StringLiteral)
+			if !ok {
+				t.Errorf("key is not ast.StringLiteral. got=%T", key)
+				continue
+			}
+			testIntegerLiteral(t, hash.Values[i], tt.expected[literal.Value])
+		}
+	}
+}
+
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`
+
+	lexer := lexer.New(input)
+	parser := MakeNewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+	expected := "{one: (0 + 1), two: (10 - 8), three: (15 / 5)}"
+	if hash.String() != expected {
+		t.Errorf("hash.String() wrong. expected=%q, got=%q", expected, hash.String())
+	}
+}
+
+func TestParsingHashLiteralErrors(t *testing.T) {
+	lexer := lexer.New(`{"one" 1}`)
+	parser := MakeNewParser(lexer)
+	parser.ParseProgram()
+
+	if len(parser.Errors()) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+	expected := "expected next token to be :, got 1 instead"
+	if parser.Errors()[0] != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, parser.Errors()[0])
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		path     string
+		alias    string
+		expected string
+	}{
+		{`import "lib/strings.mk";`, "lib/strings.mk", "", `import "lib/strings.mk";`},
+		{`import "util" as u`, "util", "u", `import "util" as u;`},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.New(tt.input)
+		parser := MakeNewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program has wrong number of statements. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("statement is not ast.ImportStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Path != tt.path {
+			t.Errorf("stmt.Path wrong. expected=%q, got=%q", tt.path, stmt.Path)
+		}
+		if tt.alias == "" && stmt.Alias != nil {
+			t.Errorf("stmt.Alias not nil. got=%s", stmt.Alias)
+		}
+		if tt.alias != "" && (stmt.Alias == nil || stmt.Alias.Value != tt.alias) {
+			t.Errorf("stmt.Alias wrong. expected=%q, got=%v", tt.alias, stmt.Alias)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong. expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestExportStatement(t *testing.T) {
+	lexer := lexer.New(`export let add = fn(a, b) { a + b };`)
+	parser := MakeNewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	stmt, ok := program.Statements[0].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ExportStatement. got=%T", program.Statements[0])
+	}
+	if !testLetStatement(t, stmt.Statement, "add") {
+		return
+	}
+}
+
+func TestImportExportErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import mod`, "expected next token to be STRING, got mod instead"},
+		{`import "mod" as "m"`, "expected next token to be IDENT, got m instead"},
+		{`export fn() {}`, "expected next token to be LET, got fn instead"},
+		{`if (true) { export let x = 1; }`, "export is only allowed at the top level"},
+		{`let f = fn() { export let x = 1; x }`, "export is only allowed at the top level"},
+		{`m.1`, "expected next token to be IDENT, got 1 instead"},
+	}
+
+	for _, tt := range tests {
+		parser := MakeNewParser(lexer.New(tt.input))
+		parser.ParseProgram()
+		if len(parser.Errors()) == 0 {
+			t.Errorf("%s: expected parser errors, got none", tt.input)
+			continue
+		}
+		if parser.Errors()[0] != tt.expected {
+			t.Errorf("%s: wrong error. expected=%q, got=%q", tt.input, tt.expected, parser.Errors()[0])
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		str      string
+	}{
+		{"3.25", 3.25, "3.25"},
+		{"-0.5", 0.5, "(-0.5)"},
+		{"1.5 + 2", 1.5, "(1.5 + 2)"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.New(tt.input)
+		parser := MakeNewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser)
+
+		if program.String() != tt.str {
+			t.Errorf("program.String() wrong. expected=%q, got=%q", tt.str, program.String())
+		}
+		var literal *ast.FloatLiteral
+		exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+		switch exp := exp.(type) {
+		case *ast.FloatLiteral:
+			literal = exp
+		case *ast.PrefixExpression:
+			literal, _ = exp.Right.(*ast.FloatLiteral)
+		case *ast.InfixExpression:
+			literal, _ = exp.Left.(*ast.FloatLiteral)
+		}
+		if literal == nil {
+			t.Fatalf("%s: no ast.FloatLiteral found", tt.input)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { risky() } catch (e) { e.message }`, "try risky() catch(e) (e.message)"},
+		{`try { risky() } catch { 0 } finally { done() }`, "try risky() catch 0 finally done()"},
+		{`try { risky() } finally { done() }`, "try risky() finally done()"},
+		{`throw "boom";`, `throw boom;`},
+		{`throw error(1 + 2)`, `throw error((1 + 2));`},
+	}
+
+	for _, tt := range tests {
+		parser := MakeNewParser(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	parser := MakeNewParser(lexer.New(`let x = try { 1 } catch (e) { 2 };`))
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+	let := program.Statements[0].(*ast.LetStatement)
+	te, ok := let.Value.(*ast.TryExpression)
+	if !ok {
+		t.Fatalf("let.Value is not ast.TryExpression. got=%T", let.Value)
+	}
+	if te.Param == nil || te.Param.Value != "e" {
+		t.Errorf("te.Param wrong. got=%v", te.Param)
+	}
+}
+
+func TestTryExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { 1 }`, "expected catch or finally after try block"},
+		{`try { 1 } catch (1) { 2 }`, "expected next token to be IDENT, got 1 instead"},
+		{`try 1`, "expected next token to be {, got 1 instead"},
+	}
+
+	for _, tt := range tests {
+		parser := MakeNewParser(lexer.New(tt.input))
+		parser.ParseProgram()
+		if len(parser.Errors()) == 0 {
+			t.Errorf("%s: expected parser errors, got none", tt.input)
+			continue
+		}
+		if parser.Errors()[0] != tt.expected {
+			t.Errorf("%s: wrong error. expected=%q, got=%q", tt.input, tt.expected, parser.Errors()[0])
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 1;\n  x + \"a${b}c\";"
+	expected := []struct {
+		literal      string
+		line, column int
+	}{
+		{"let", 1, 1}, {"x", 1, 5}, {"=", 1, 7}, {"1", 1, 9}, {";", 1, 10},
+		{"x", 2, 3}, {"+", 2, 5}, {"a", 2, 7}, {"b", 2, 11}, {"c", 2, 12}, {";", 2, 15},
+	}
+
+	l := lexer.New(input)
+	for _, want := range expected {
+		tok := l.NextToken()
+		if tok.Literal != want.literal || tok.Line != want.line || tok.Column != want.column {
+			t.Errorf("wrong token. expected=%q at %d:%d, got=%q at %d:%d",
+				want.literal, want.line, want.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	input := `match (x) {
+		1 => "one",
+		-2 | 2.5 => "two",
+		"a" | "b" => { let y = 1; y },
+		[a, _] if a > 0 => a,
+		{name: n, "age": 30, id} => n,
+		_ => 0,
+	}`
+	expected := `match (x) { 1 => one, -2 | 2.5 => two, "a" | "b" => { let y = 1;y }, ` +
+		`[a, _] if (a > 0) => a, {name: n, "age": 30, id: id} => n, _ => 0 }`
+
+	parser := MakeNewParser(lexer.New(input))
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	me, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("expression is not ast.MatchExpression. got=%T", program.Statements[0])
+	}
+	if len(me.Arms) != 6 {
+		t.Fatalf("wrong number of arms. got=%d", len(me.Arms))
+	}
+	if _, ok := me.Arms[2].Pattern.(*ast.AlternativePattern); !ok {
+		t.Errorf("arm 2 pattern is not ast.AlternativePattern. got=%T", me.Arms[2].Pattern)
+	}
+	if me.Arms[3].Guard == nil {
+		t.Errorf("arm 3 has no guard")
+	}
+	if _, ok := me.Arms[5].Pattern.(*ast.WildcardPattern); !ok {
+		t.Errorf("arm 5 pattern is not ast.WildcardPattern. got=%T", me.Arms[5].Pattern)
+	}
+	if program.String() != expected {
+		t.Errorf("program.String() wrong.\nexpected=%q\ngot=%q", expected, program.String())
+	}
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match x { _ => 1 }`, "expected next token to be (, got x instead"},
+		{`match (x) { 1 -> 2 }`, "expected next token to be =>, got - instead"},
+		{`match (x) { fn => 2 }`, "unexpected fn in pattern"},
+		{`match (x) { {1 + 2: y} => y }`, "expected next token to be :, got + instead"},
+		{`match (x) { [a, b => 1 }`, "expected next token to be ], got => instead"},
+		{`match (x) { 1 => }`, "expected expression after =>, got }"},
+	}
+
+	for _, tt := range tests {
+		parser := MakeNewParser(lexer.New(tt.input))
+		parser.ParseProgram()
+		if len(parser.Errors()) == 0 {
+			t.Errorf("%s: expected parser errors, got none", tt.input)
+			continue
+		}
+		if parser.Errors()[0] != tt.expected {
+			t.Errorf("%s: wrong error. expected=%q, got=%q", tt.input, tt.expected, parser.Errors()[0])
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let [a, b, ...rest] = arr;`, `let [a, b, ...rest] = arr;`},
+		{`let {x, y: [_, y]} = point;`, `let {x: x, y: [_, y]} = point;`},
+		{`let f = fn([a, b], c, {d}) { a };`, `let f = fn([a, b], c, {d: d})a;`},
+		{`match (x) { [h, ...t] => t }`, `match (x) { [h, ...t] => t }`},
+	}
+
+	for _, tt := range tests {
+		parser := MakeNewParser(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong.\nexpected=%q\ngot=%q", tt.expected, program.String())
+		}
+	}
+
+	parser := MakeNewParser(lexer.New(`fn(a, [b, c], d) { b }`))
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(function.Parameters) != 3 {
+		t.Fatalf("wrong number of parameters. got=%d", len(function.Parameters))
+	}
+	testParameter(t, function.Parameters[0], "a")
+	if _, ok := function.Parameters[1].(*ast.ArrayPattern); !ok {
+		t.Errorf("parameter 1 is not ast.ArrayPattern. got=%T", function.Parameters[1])
+	}
+	testParameter(t, function.Parameters[2], "d")
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let [a, ...rest, b] = x;`, "expected next token to be ], got , instead"},
+		{`let [a, ...] = x;`, "expected next token to be IDENT, got ] instead"},
+		{`let {a} x;`, "expected next token to be =, got x instead"},
+		{`fn([a, b) { a }`, "expected next token to be ], got ) instead"},
+	}
+
+	for _, tt := range tests {
+		parser := MakeNewParser(lexer.New(tt.input))
+		parser.ParseProgram()
+		if len(parser.Errors()) == 0 {
+			t.Errorf("%s: expected parser errors, got none", tt.input)
+			continue
+		}
+		if parser.Errors()[0] != tt.expected {
+			t.Errorf("%s: wrong error. expected=%q, got=%q", tt.input, tt.expected, parser.Errors()[0])
+		}
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	parser := MakeNewParser(lexer.New(input))
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	macro, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("expression is not ast.MacroLiteral. got=%T", program.Statements[0])
+	}
+	if len(macro.Parameters) != 2 || macro.Parameters[0].Value != "x" || macro.Parameters[1].Value != "y" {
+		t.Fatalf("wrong macro parameters. got=%v", macro.Parameters)
+	}
+	if macro.String() != "macro(x, y)(x + y)" {
+		t.Errorf("macro.String() wrong. got=%q", macro.String())
+	}
+
+	parser = MakeNewParser(lexer.New(`macro([a, b]) { a }`))
+	parser.ParseProgram()
+	if len(parser.Errors()) == 0 || parser.Errors()[0] != "macro parameters cannot be destructured" {
+		t.Errorf("wrong errors. got=%q", parser.Errors())
+	}
+}
+
+func TestCommentsAreSkipped(t *testing.T) {
+	input := `// leading
+let x = 10 // after x
+	/ 2; // a single slash still divides
+// trailing`
+
+	l := lexer.New(input)
+	parser := MakeNewParser(l)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser)
+
+	if program.String() != "let x = (10 / 2);" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+	comments := l.Comments()
+	expected := []string{"// leading", "// after x", "// a single slash still divides", "// trailing"}
+	if len(comments) != len(expected) {
+		t.Fatalf("wrong number of comments. got=%d", len(comments))
+	}
+	for i, comment := range comments {
+		if comment.Type != token.COMMENT || comment.Literal != expected[i] {
+			t.Errorf("comments[%d] wrong. got=%+v", i, comment)
+		}
+	}
+	if comments[1].Line != 2 || comments[1].Column != 12 {
+		t.Errorf("wrong position for comments[1]. got=%d:%d", comments[1].Line, comments[1].Column)
+	}
+}
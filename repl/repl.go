@@ -30,6 +30,9 @@ $$/  $$/  $$/  $$$$$$/  $$/   $$/ $$/   $$/  $$$$$$$/  $$$$$$$ |
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment(nil)
+	macros := object.NewEnvironment(nil)
+	e := evaluator.New()
+	e.Out = out
 
 	for {
 		fmt.Fprint(out, PROMPT)
@@ -46,8 +49,18 @@ func Start(in io.Reader, out io.Writer) {
 			printParseError(out, p.Errors())
 			continue
 		}
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
+		evaluator.DefineMacros(program, macros)
+		expanded, err := e.ExpandMacros(program, macros)
+		if err != nil {
+			io.WriteString(out, err.StackTrace())
+			io.WriteString(out, "\n")
+			continue
+		}
+		evaluated := e.Eval(expanded, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			io.WriteString(out, err.StackTrace())
+			io.WriteString(out, "\n")
+		} else if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
@@ -5,6 +5,9 @@ type TokenType	string
 type Token struct {
 	Type TokenType
 	Literal string
+	//토큰이 시작하는 위치 (1부터 시작)
+	Line int
+	Column int
 }
 
 
@@ -15,7 +18,16 @@ const (
 	//식별자 + 리터럴
 	IDENT = "IDENT"
 	INT = "INT"
+	FLOAT = "FLOAT"
 	STRING = "STRING"
+
+	//문자열 보간: "head ${ ... } mid ${ ... } end"
+	INTERP_START = "INTERP_START"
+	INTERP_MID = "INTERP_MID"
+	INTERP_END = "INTERP_END"
+
+	//주석: "//" 부터 줄 끝까지. 파서에는 전달되지 않는다
+	COMMENT = "COMMENT"
 	
 	//연산자
 	ASSIGN = "="
@@ -36,6 +48,11 @@ const (
 	//구분자
 	COMMA = ","
 	SEMICOLON = ";"
+	COLON = ":"
+	DOT = "."
+	ARROW = "=>"
+	PIPE = "|"
+	ELLIPSIS = "..."
 
 	LPAREN = "("
 	RPAREN = ")"
@@ -50,6 +67,15 @@ const (
 	TRUE = "TRUE"
 	FALSE = "FALSE"
 	RETURN = "RETURN"
+	IMPORT = "IMPORT"
+	EXPORT = "EXPORT"
+	AS = "AS"
+	TRY = "TRY"
+	CATCH = "CATCH"
+	FINALLY = "FINALLY"
+	THROW = "THROW"
+	MATCH = "MATCH"
+	MACRO = "MACRO"
 )
 
 var keywords = map[string]TokenType {
@@ -60,6 +86,15 @@ var keywords = map[string]TokenType {
 	"return": RETURN,
 	"true": TRUE,
 	"false": FALSE,
+	"import": IMPORT,
+	"export": EXPORT,
+	"as": AS,
+	"try": TRY,
+	"catch": CATCH,
+	"finally": FINALLY,
+	"throw": THROW,
+	"match": MATCH,
+	"macro": MACRO,
 }
 
 func LookupIdent(ident string) TokenType{